@@ -0,0 +1,254 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// RelativeImages are the relative image links of one readme refused by the archive
+type RelativeImages struct {
+	README string   `json:"readme" binding:"required"`
+	FILE   string   `json:"file" binding:"required"`
+	LINKS  []string `json:"links" binding:"required"`
+}
+
+// RelativeImagesResponse names every image link that would point at nothing in the archive
+type RelativeImagesResponse struct {
+	MESSAGE string           `json:"message" binding:"required"`
+	IMAGES  []RelativeImages `json:"images" binding:"required"`
+}
+
+// archiveFile is one rendered readme inside an archive
+type archiveFile struct {
+	readme   string
+	name     string
+	markdown []byte
+	modified time.Time
+	// relativeImages are the image links that point next to the readme, there is nothing to put at them
+	relativeImages []string
+}
+
+// archiveReadmes picks the readmes named by id, or every readme starting with prefix
+func (rc *readmeController) archiveReadmes(readmeIds []string, prefix string) ([]Readme, error) {
+	if len(readmeIds) == 0 {
+		summaries, err := rc.store.List()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, summary := range summaries {
+			if strings.HasPrefix(summary.NAME, prefix) {
+				readmeIds = append(readmeIds, summary.NAME)
+			}
+		}
+	}
+
+	readmes := make([]Readme, 0, len(readmeIds))
+	for _, readmeId := range readmeIds {
+		readme, err := rc.store.Get(readmeId)
+		if err != nil {
+			return nil, err
+		}
+		readmes = append(readmes, readme)
+	}
+
+	return readmes, nil
+}
+
+// archiveFiles renders the readmes, file names that would clash get a number added
+func (rc *readmeController) archiveFiles(readmes []Readme) ([]archiveFile, error) {
+	used := make(map[string]bool)
+	files := make([]archiveFile, 0, len(readmes))
+
+	for _, readme := range readmes {
+		name := readmeFileName(readme.NAME)
+		for i := 2; used[name]; i++ {
+			name = strings.TrimSuffix(readmeFileName(readme.NAME), ".md") + "-" + strconv.Itoa(i) + ".md"
+		}
+		used[name] = true
+
+		rendered, err := rc.render(readme)
+		if err != nil {
+			return nil, interpolationError{readme.NAME + ": " + err.Error()}
+		}
+
+		files = append(files, archiveFile{readme: readme.NAME, name: name, markdown: []byte(renderReadme(rendered)), modified: readme.CREATED_AT, relativeImages: relativeImages(rendered)})
+	}
+
+	return files, nil
+}
+
+// relativeImage reports whether the image link points at a file relative to the readme rather than a url
+func relativeImage(link string) bool {
+	parsed, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return true
+	}
+
+	return parsed.Scheme == "" && parsed.Host == ""
+}
+
+// relativeImages returns the relative image links of the rendered readme, images written in raw markdown included
+func relativeImages(readme Readme) []string {
+	var links []string
+	for _, element := range readme.ELEMENTS {
+		switch element.TYPE {
+		case ImageElement:
+			if relativeImage(element.LINK) {
+				links = append(links, element.LINK)
+			}
+		case RawElement:
+			source := []byte(element.VALUE)
+			ast.Walk(rawMarkdown.Parser().Parse(text.NewReader(source)), func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+				if image, ok := node.(*ast.Image); ok && entering && relativeImage(string(image.Destination)) {
+					links = append(links, string(image.Destination))
+				}
+				return ast.WalkContinue, nil
+			})
+		}
+	}
+
+	return links
+}
+
+// missingImages lists the relative images of every file, empty when there are none
+func missingImages(files []archiveFile) []RelativeImages {
+	var missing []RelativeImages
+	for _, file := range files {
+		if len(file.relativeImages) > 0 {
+			missing = append(missing, RelativeImages{README: file.readme, FILE: file.name, LINKS: file.relativeImages})
+		}
+	}
+
+	return missing
+}
+
+func writeZip(w io.Writer, files []archiveFile) error {
+	archive := zip.NewWriter(w)
+	for _, file := range files {
+		f, err := archive.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: file.modified})
+		if err != nil {
+			return err
+		}
+
+		if _, err := f.Write(file.markdown); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+func writeTarGz(w io.Writer, files []archiveFile) error {
+	compressed := gzip.NewWriter(w)
+	archive := tar.NewWriter(compressed)
+	for _, file := range files {
+		header := &tar.Header{Name: file.name, Mode: 0644, Size: int64(len(file.markdown)), ModTime: file.modified, Typeflag: tar.TypeReg}
+		if err := archive.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if _, err := archive.Write(file.markdown); err != nil {
+			return err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return err
+	}
+
+	return compressed.Close()
+}
+
+// ArchiveReadmes godoc
+// @Summary Downloads many readmes as one archive
+// @Description Renders every readme named by id, or every readme whose name starts with prefix, into a zip or tar.gz archive.
+// @Description Each readme is a markdown file named after it. The server has no image uploads, images are only links in the
+// @Description markdown, so there are no image files to bundle. Readmes with relative image links are refused with the links
+// @Description listed unless allow_relative_images=true.
+// @Accept json
+// @Produce application/zip
+// @Produce application/gzip
+// @Param	id	query	[]string	false	"readme ids, repeat for more than one"	collectionFormat(multi)
+// @Param	prefix	query	string	false	"archive every readme whose name starts with prefix"
+// @Param	format	query	string	false	"zip (default) or tar.gz"
+// @Param	allow_relative_images	query	bool	false	"archive readmes whose relative image links will point at nothing"
+// @Success 200	{file}	file	"the archive"
+// @Failure 400	{object}	HttpErrorMessage	"incorrect query"
+// @Failure 404	{object}	HttpErrorMessage	"could not find readme"
+// @Failure 422	{object}	HttpErrorMessage	"a readme uses undefined variables"
+// @Failure 422	{object}	RelativeImagesResponse	"the relative image links that are not in the archive"
+// @Router 	/readme/archive	[get]
+func (rc *readmeController) archive(c *gin.Context) {
+	readmeIds := c.QueryArray("id")
+	prefix, hasPrefix := c.GetQuery("prefix")
+	format := c.DefaultQuery("format", "zip")
+
+	if len(readmeIds) == 0 && !hasPrefix {
+		c.IndentedJSON(http.StatusBadRequest, HttpErrorMessage{MESSAGE: "pass the readmes to archive as id or prefix"})
+		return
+	}
+
+	if len(readmeIds) > 0 && hasPrefix {
+		c.IndentedJSON(http.StatusBadRequest, HttpErrorMessage{MESSAGE: "pass either id or prefix, not both"})
+		return
+	}
+
+	write, contentType := writeZip, "application/zip"
+	switch format {
+	case "zip":
+	case "tar.gz":
+		write, contentType = writeTarGz, "application/gzip"
+	default:
+		c.IndentedJSON(http.StatusBadRequest, HttpErrorMessage{MESSAGE: "format should be zip or tar.gz"})
+		return
+	}
+
+	// every readme is loaded and rendered before anything is written so errors can still be reported
+	readmes, err := rc.archiveReadmes(readmeIds, prefix)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	files, err := rc.archiveFiles(readmes)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	if missing := missingImages(files); len(missing) > 0 && c.Query("allow_relative_images") != "true" {
+		links := make([]string, len(missing))
+		for i, images := range missing {
+			links[i] = images.FILE + " (" + strings.Join(images.LINKS, ", ") + ")"
+		}
+
+		c.IndentedJSON(http.StatusUnprocessableEntity, RelativeImagesResponse{
+			MESSAGE: "relative image links would be broken in the archive: " + strings.Join(links, "; ") + ", pass allow_relative_images=true to archive anyway",
+			IMAGES:  missing,
+		})
+		return
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": "readmes." + format}))
+	c.Status(http.StatusOK)
+
+	if err := write(c.Writer, files); err != nil {
+		// the headers are already out, all that can be done is to stop
+		c.Error(err)
+		c.Abort()
+	}
+}
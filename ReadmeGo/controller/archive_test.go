@@ -0,0 +1,150 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func setupArchiveReadmes(t *testing.T) *gin.Engine {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+
+	for _, name := range []string{"svc-billing", "svc-payments", "svc payments", "website"} {
+		req, _ := http.NewRequest("POST", "/readme?name="+name, nil)
+		router.ServeHTTP(w, req)
+		addParagraph(t, router, name, name+" readme")
+	}
+
+	return router
+}
+
+func readZip(t *testing.T, body []byte) map[string]string {
+	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	require.NoError(t, err)
+
+	files := make(map[string]string)
+	for _, file := range archive.File {
+		f, err := file.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(f)
+		require.NoError(t, err)
+		files[file.Name] = string(data)
+	}
+
+	return files
+}
+
+func TestArchiveReadmesAsZip(t *testing.T) {
+	router := setupArchiveReadmes(t)
+	r := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("GET", "/readme/archive?prefix=svc", nil)
+	router.ServeHTTP(r, req)
+
+	require.Equal(t, http.StatusOK, r.Code)
+	require.Equal(t, "application/zip", r.Header().Get("Content-Type"))
+	require.Equal(t, "attachment; filename=readmes.zip", r.Header().Get("Content-Disposition"))
+	require.Equal(t, map[string]string{
+		"svc-payments.md":   "svc payments readme\n",
+		"svc-billing.md":    "svc-billing readme\n",
+		"svc-payments-2.md": "svc-payments readme\n",
+	}, readZip(t, r.Body.Bytes()))
+}
+
+func TestArchiveReadmesAsTarGz(t *testing.T) {
+	router := setupArchiveReadmes(t)
+	r := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("GET", "/readme/archive?id=website&id=svc-billing&format=tar.gz", nil)
+	router.ServeHTTP(r, req)
+
+	require.Equal(t, http.StatusOK, r.Code)
+	require.Equal(t, "application/gzip", r.Header().Get("Content-Type"))
+
+	compressed, err := gzip.NewReader(r.Body)
+	require.NoError(t, err)
+	archive := tar.NewReader(compressed)
+
+	files := make(map[string]string)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		data, err := io.ReadAll(archive)
+		require.NoError(t, err)
+		files[header.Name] = string(data)
+	}
+
+	require.Equal(t, map[string]string{"website.md": "website readme\n", "svc-billing.md": "svc-billing readme\n"}, files)
+}
+
+func TestArchiveReadmesReturnsIncorrectQuery(t *testing.T) {
+	router := setupArchiveReadmes(t)
+
+	for query, code := range map[string]int{
+		"":                       http.StatusBadRequest,
+		"?id=website&prefix=svc": http.StatusBadRequest,
+		"?prefix=svc&format=rar": http.StatusBadRequest,
+		"?id=website&id=INVALID": http.StatusNotFound,
+	} {
+		r := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/readme/archive"+query, nil)
+		router.ServeHTTP(r, req)
+
+		require.Equal(t, code, r.Code, query)
+	}
+}
+
+func TestArchiveReadmesRefusesRelativeImages(t *testing.T) {
+	router := setupArchiveReadmes(t)
+	w := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("PUT", "/readme/website/image", bytes.NewBufferString(`{"description": "logo", "link": "https://example.com/logo.png"}`))
+	router.ServeHTTP(w, req1)
+
+	r := httptest.NewRecorder()
+	req2, _ := http.NewRequest("GET", "/readme/archive?prefix=web", nil)
+	router.ServeHTTP(r, req2)
+
+	require.Equal(t, http.StatusOK, r.Code)
+
+	req3, _ := http.NewRequest("PUT", "/readme/website/image", bytes.NewBufferString(`{"description": "diagram", "link": "docs/diagram.png"}`))
+	router.ServeHTTP(w, req3)
+
+	r = httptest.NewRecorder()
+	req4, _ := http.NewRequest("GET", "/readme/archive?prefix=web", nil)
+	router.ServeHTTP(r, req4)
+
+	require.Equal(t, http.StatusUnprocessableEntity, r.Code)
+	require.JSONEq(t, `{
+		"message": "relative image links would be broken in the archive: website.md (docs/diagram.png), pass allow_relative_images=true to archive anyway",
+		"images": [{"readme": "website", "file": "website.md", "links": ["docs/diagram.png"]}]
+	}`, r.Body.String())
+
+	r = httptest.NewRecorder()
+	req5, _ := http.NewRequest("GET", "/readme/archive?prefix=web&allow_relative_images=true", nil)
+	router.ServeHTTP(r, req5)
+
+	require.Equal(t, http.StatusOK, r.Code)
+	require.Contains(t, readZip(t, r.Body.Bytes())["website.md"], "![diagram](docs/diagram.png)")
+}
+
+func TestRelativeImagesInRawMarkdown(t *testing.T) {
+	readme := Readme{ELEMENTS: []Element{
+		{TYPE: RawElement, VALUE: "# Title\n\n![arch](./arch.svg) and ![badge](https://img.shields.io/badge.svg)\n"},
+		{TYPE: ImageElement, DESCRIPTION: "cdn", LINK: "//cdn.example.com/a.png"},
+	}}
+
+	require.Equal(t, []string{"./arch.svg"}, relativeImages(readme))
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxBatchOperations is the most operations a single batch can hold
+const maxBatchOperations = 1000
+
+const (
+	BatchApplied    = "applied"
+	BatchFailed     = "failed"
+	BatchRolledBack = "rolled back"
+	BatchNotRun     = "not run"
+)
+
+// BatchOperation is one step of a batch. The payload field matching the operation is used, the add
+// operations take the same bodies as their endpoints, add_paragraph and add_blockquote take the text, and
+// delete and move take the element id. update takes the element id and the new element like the update endpoint.
+// toggle takes the element and item id of a task and sets it to checked when given, flipping it otherwise.
+type BatchOperation struct {
+	// OPERATION is one of add_header, add_paragraph, add_blockquote, add_code, add_link, add_image, add_table,
+	// add_list, add_tasklist, update, delete, move or toggle
+	OPERATION  string            `json:"operation" binding:"required"`
+	HEADER     *AddHeaderRequest `json:"header,omitempty"`
+	PARAGRAPH  *string           `json:"paragraph,omitempty"`
+	BLOCKQUOTE *string           `json:"blockquote,omitempty"`
+	CODE       *AddCodeRequest   `json:"code,omitempty"`
+	LINK       *AddLinkRequest   `json:"link,omitempty"`
+	TABLE      *AddTableRequest  `json:"table,omitempty"`
+	LIST       *AddListRequest   `json:"list,omitempty"`
+	ELEMENT    *Element          `json:"element,omitempty"`
+	ELEMENT_ID string            `json:"element_id,omitempty"`
+	ITEM_ID    string            `json:"item_id,omitempty"`
+	CHECKED    *bool             `json:"checked,omitempty"`
+	// POSITION is where an added element goes, the end of the readme by default, or where an element is moved to
+	POSITION MoveElementRequest `json:"position"`
+}
+
+type BatchRequest struct {
+	OPERATIONS []BatchOperation `json:"operations" binding:"required"`
+}
+
+type BatchResult struct {
+	INDEX      int    `json:"index" binding:"required"`
+	OPERATION  string `json:"operation" binding:"required"`
+	STATUS     string `json:"status" binding:"required"`
+	ELEMENT_ID string `json:"element_id,omitempty"`
+	MARKDOWN   string `json:"markdown,omitempty"`
+	ERROR      string `json:"error,omitempty"`
+}
+
+type BatchResponse struct {
+	REVISION int           `json:"revision,omitempty"`
+	MESSAGE  string        `json:"message,omitempty"`
+	RESULTS  []BatchResult `json:"results" binding:"required"`
+}
+
+// batchError is returned from the store update when an operation fails so nothing of the batch is saved
+type batchError struct {
+	index int
+	err   error
+}
+
+func (e batchError) Error() string {
+	return fmt.Sprintf("operation %d: %s", e.index, e.err.Error())
+}
+
+func (e batchError) Unwrap() error {
+	return e.err
+}
+
+// element builds the element an add operation inserts or an update puts in place of an element
+func (op BatchOperation) element() (Element, error) {
+	var element Element
+	// field is the json payload field the operation reads its body from
+	var field string
+	missing := false
+
+	switch op.OPERATION {
+	case "add_header":
+		if field, missing = "header", op.HEADER == nil; !missing {
+			if err := op.HEADER.validate(); err != nil {
+				return element, err
+			}
+			element = op.HEADER.element()
+		}
+	case "add_paragraph":
+		if field, missing = "paragraph", op.PARAGRAPH == nil; !missing {
+			element = Element{TYPE: ParagraphElement, VALUE: *op.PARAGRAPH}
+		}
+	case "add_blockquote":
+		if field, missing = "blockquote", op.BLOCKQUOTE == nil; !missing {
+			element = Element{TYPE: BlockquoteElement, VALUE: *op.BLOCKQUOTE}
+		}
+	case "add_code":
+		if field, missing = "code", op.CODE == nil; !missing {
+			element = op.CODE.element()
+		}
+	case "add_link", "add_image":
+		if field, missing = "link", op.LINK == nil; !missing {
+			elementType := LinkElement
+			if op.OPERATION == "add_image" {
+				elementType = ImageElement
+			}
+			element = op.LINK.element(elementType)
+		}
+	case "add_table":
+		if field, missing = "table", op.TABLE == nil; !missing {
+			element = op.TABLE.element()
+		}
+	case "add_list", "add_tasklist":
+		if field, missing = "list", op.LIST == nil; !missing {
+			elementType := ListElement
+			if op.OPERATION == "add_tasklist" {
+				elementType = TaskListElement
+			}
+			element = op.LIST.element(elementType)
+		}
+	case "update":
+		if field, missing = "element", op.ELEMENT == nil; !missing {
+			element = *op.ELEMENT
+		}
+	}
+
+	if missing {
+		return element, invalidRequestError{op.OPERATION + " needs its body in " + field}
+	}
+
+	if err := validateElement(element); err != nil {
+		return element, err
+	}
+
+	element.ID = uuid.NewString()
+	return element, nil
+}
+
+// apply runs the operation against the readme and fills in its result
+func (op BatchOperation) apply(readme *Readme, result *BatchResult) error {
+	switch op.OPERATION {
+	case "add_header", "add_paragraph", "add_blockquote", "add_code", "add_link", "add_image", "add_table", "add_list", "add_tasklist":
+		element, err := op.element()
+		if err != nil {
+			return err
+		}
+
+		if op.POSITION.count() > 1 {
+			return invalidRequestError{"only one of before, after or index can be set"}
+		}
+
+		index, err := op.POSITION.index(readme)
+		if err != nil {
+			return err
+		}
+
+		readme.insertAt(index, element)
+		result.ELEMENT_ID = element.ID
+		result.MARKDOWN = renderElement(element)
+	case "update":
+		element, err := op.element()
+		if err != nil {
+			return err
+		}
+
+		index, err := readme.indexOf(op.ELEMENT_ID)
+		if err != nil {
+			return err
+		}
+
+		element.ID = op.ELEMENT_ID
+		readme.ELEMENTS[index] = element
+		result.ELEMENT_ID = element.ID
+		result.MARKDOWN = renderElement(element)
+	case "delete":
+		index, err := readme.indexOf(op.ELEMENT_ID)
+		if err != nil {
+			return err
+		}
+
+		readme.ELEMENTS = append(readme.ELEMENTS[:index], readme.ELEMENTS[index+1:]...)
+		result.ELEMENT_ID = op.ELEMENT_ID
+	case "move":
+		if err := moveElement(readme, op.ELEMENT_ID, op.POSITION); err != nil {
+			return err
+		}
+
+		result.ELEMENT_ID = op.ELEMENT_ID
+	case "toggle":
+		if _, err := toggleTask(readme, op.ELEMENT_ID, op.ITEM_ID, op.CHECKED); err != nil {
+			return err
+		}
+
+		index, _ := readme.indexOf(op.ELEMENT_ID)
+		result.ELEMENT_ID = op.ELEMENT_ID
+		result.MARKDOWN = renderElement(readme.ELEMENTS[index])
+	default:
+		return invalidRequestError{"unknown operation " + strconv.Quote(op.OPERATION)}
+	}
+
+	return nil
+}
+
+// ApplyBatch godoc
+// @Summary Applies several element operations at once
+// @Description Applies the operations in order as one change: either every operation succeeds or the readme is left as it was.
+// @Description Operations are add_header, add_code, add_link, add_image, add_table, add_list and add_tasklist, taking the same body as their endpoint
+// @Description in the matching field, add_paragraph and add_blockquote, taking the text in paragraph or blockquote, update, taking element_id
+// @Description and the new element in element, delete and move, taking element_id, and toggle, taking element_id, item_id and optionally checked.
+// @Description Later operations see the elements added by earlier ones.
+// @Description The whole batch is a single revision and is undone in one step.
+// @Accept json
+// @Produce json
+// @Param	id	path	string	true	"readme id"
+// @Param	batchRequest	body	BatchRequest	true	"the operations in order"
+// @Param	X-Author	header	string	false	"who made the change"
+// @Success	200	{object}	BatchResponse	"the revision and the result of every operation"
+// @Failure 400	{object}	BatchResponse	"an operation was invalid, nothing was applied"
+// @Failure 404	{object}	BatchResponse	"could not find readme or an element, nothing was applied"
+// @Router	/readme/{id}/batch	[post]
+func (rc *readmeController) applyBatch(c *gin.Context) {
+	readmeId := c.Param("id")
+	var batchRequest BatchRequest
+
+	if err := c.BindJSON(&batchRequest); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, HttpErrorMessage{MESSAGE: "incorrect request body, should be BatchRequest body"})
+		return
+	}
+
+	if len(batchRequest.OPERATIONS) == 0 {
+		c.IndentedJSON(http.StatusBadRequest, HttpErrorMessage{MESSAGE: "batch needs at least one operation"})
+		return
+	}
+
+	if len(batchRequest.OPERATIONS) > maxBatchOperations {
+		c.IndentedJSON(http.StatusBadRequest, HttpErrorMessage{MESSAGE: "batch can hold at most " + strconv.Itoa(maxBatchOperations) + " operations"})
+		return
+	}
+
+	var results []BatchResult
+	revision, err := rc.update(c, readmeId, "batch of "+strconv.Itoa(len(batchRequest.OPERATIONS))+" operations", func(readme *Readme) error {
+		results = make([]BatchResult, len(batchRequest.OPERATIONS))
+		for i, op := range batchRequest.OPERATIONS {
+			results[i] = BatchResult{INDEX: i, OPERATION: op.OPERATION, STATUS: BatchApplied}
+			if err := op.apply(readme, &results[i]); err != nil {
+				return batchError{index: i, err: err}
+			}
+		}
+		return nil
+	})
+
+	var failed batchError
+	if errors.As(err, &failed) {
+		status, message := storeErrorStatus(failed.err)
+		for i := range results {
+			switch {
+			case i < failed.index:
+				// the ids of rolled back elements were never saved
+				results[i] = BatchResult{INDEX: i, OPERATION: results[i].OPERATION, STATUS: BatchRolledBack}
+			case i == failed.index:
+				results[i].STATUS = BatchFailed
+				results[i].ERROR = message
+			default:
+				results[i] = BatchResult{INDEX: i, OPERATION: batchRequest.OPERATIONS[i].OPERATION, STATUS: BatchNotRun}
+			}
+		}
+
+		c.IndentedJSON(status, BatchResponse{MESSAGE: "operation " + strconv.Itoa(failed.index) + " failed, nothing was applied", RESULTS: results})
+		return
+	}
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, BatchResponse{REVISION: revision.NUMBER, RESULTS: results})
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyBatch(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			router := setupRouter(store)
+			w := httptest.NewRecorder()
+
+			req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+			router.ServeHTTP(w, req1)
+
+			first := addParagraph(t, router, "1", "first")
+			second := addParagraph(t, router, "1", "second")
+
+			batch := `{"operations": [
+				{"operation": "add_header", "header": {"header_type": "LARGE_HEADING", "value": "Title"}, "position": {"index": 0}},
+				{"operation": "add_table", "table": {"column_names": ["a"], "column_values": {"a": ["1"]}}},
+				{"operation": "delete", "element_id": "` + first + `"},
+				{"operation": "move", "element_id": "` + second + `", "position": {"index": 0}}
+			]}`
+
+			r := httptest.NewRecorder()
+			req2, _ := http.NewRequest("POST", "/readme/1/batch", bytes.NewBufferString(batch))
+			router.ServeHTTP(r, req2)
+
+			require.Equal(t, http.StatusOK, r.Code)
+
+			var response BatchResponse
+			require.NoError(t, json.Unmarshal(r.Body.Bytes(), &response))
+			require.Len(t, response.RESULTS, 4)
+			require.Equal(t, "# Title\n", response.RESULTS[0].MARKDOWN)
+			require.Equal(t, second, response.RESULTS[3].ELEMENT_ID)
+			for _, result := range response.RESULTS {
+				require.Equal(t, BatchApplied, result.STATUS)
+			}
+
+			require.Equal(t, []string{"second\n", "# Title\n", "|a|\n| --- |\n|1|\n"}, getRenderedReadme(t, router, "1"))
+
+			// the whole batch is undone in one step
+			req3, _ := http.NewRequest("POST", "/readme/1/undo", nil)
+			router.ServeHTTP(w, req3)
+
+			require.Equal(t, []string{"first\n", "second\n"}, getRenderedReadme(t, router, "1"))
+		})
+	}
+}
+
+func TestApplyBatchAppliesNothingWhenAnOperationFails(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			router := setupRouter(store)
+			w := httptest.NewRecorder()
+
+			req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+			router.ServeHTTP(w, req1)
+
+			first := addParagraph(t, router, "1", "first")
+
+			batch := `{"operations": [
+				{"operation": "add_header", "header": {"header_type": "MEDIUM_HEADING", "value": "Title"}},
+				{"operation": "delete", "element_id": "` + first + `"},
+				{"operation": "move", "element_id": "` + first + `", "position": {"index": 0}},
+				{"operation": "add_code", "code": {"code_language": "go", "value": "package main"}}
+			]}`
+
+			r := httptest.NewRecorder()
+			req2, _ := http.NewRequest("POST", "/readme/1/batch", bytes.NewBufferString(batch))
+			router.ServeHTTP(r, req2)
+
+			require.Equal(t, http.StatusNotFound, r.Code)
+
+			var response BatchResponse
+			require.NoError(t, json.Unmarshal(r.Body.Bytes(), &response))
+			require.Equal(t, []BatchResult{
+				{INDEX: 0, OPERATION: "add_header", STATUS: BatchRolledBack},
+				{INDEX: 1, OPERATION: "delete", STATUS: BatchRolledBack},
+				{INDEX: 2, OPERATION: "move", STATUS: BatchFailed, ERROR: "could not find element"},
+				{INDEX: 3, OPERATION: "add_code", STATUS: BatchNotRun},
+			}, response.RESULTS)
+
+			require.Equal(t, []string{"first\n"}, getRenderedReadme(t, router, "1"))
+		})
+	}
+}
+
+func TestApplyBatchReturnsInvalidOperation(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+	router.ServeHTTP(w, req1)
+
+	for _, batch := range []string{
+		`{"operations": []}`,
+		`{"operations": [{"operation": "add_header"}]}`,
+		`{"operations": [{"operation": "add_code", "code": {"code_language": "cobol", "value": "x"}}]}`,
+		`{"operations": [{"operation": "rename"}]}`,
+	} {
+		r := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/readme/1/batch", bytes.NewBufferString(batch))
+		router.ServeHTTP(r, req)
+
+		require.Equal(t, http.StatusBadRequest, r.Code, batch)
+	}
+
+	require.Empty(t, getRenderedReadme(t, router, "1"))
+}
+
+func TestApplyBatchReturnsReadmeNotFound(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("POST", "/readme/missing/batch", bytes.NewBufferString(`{"operations": [{"operation": "delete", "element_id": "x"}]}`))
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestApplyBatchNamesTheMissingPayloadField(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+	router.ServeHTTP(w, req1)
+
+	for operation, message := range map[string]string{
+		"add_header":   "add_header needs its body in header",
+		"add_image":    "add_image needs its body in link",
+		"add_tasklist": "add_tasklist needs its body in list",
+	} {
+		r := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/readme/1/batch", bytes.NewBufferString(`{"operations": [{"operation": "`+operation+`"}]}`))
+		router.ServeHTTP(r, req)
+
+		var response BatchResponse
+		require.NoError(t, json.Unmarshal(r.Body.Bytes(), &response))
+		require.Equal(t, message, response.RESULTS[0].ERROR)
+	}
+}
+
+func TestApplyBatchWritesAndUpdatesText(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+	router.ServeHTTP(w, req1)
+
+	first := addParagraph(t, router, "1", "first")
+
+	batch := `{"operations": [
+		{"operation": "add_paragraph", "paragraph": "Handles invoices"},
+		{"operation": "add_blockquote", "blockquote": "Ask the platform team"},
+		{"operation": "update", "element_id": "` + first + `", "element": {"type": "header", "level": 1, "value": "Billing"}}
+	]}`
+
+	r := httptest.NewRecorder()
+	req2, _ := http.NewRequest("POST", "/readme/1/batch", bytes.NewBufferString(batch))
+	router.ServeHTTP(r, req2)
+
+	require.Equal(t, http.StatusOK, r.Code, r.Body.String())
+
+	var response BatchResponse
+	require.NoError(t, json.Unmarshal(r.Body.Bytes(), &response))
+	require.Equal(t, first, response.RESULTS[2].ELEMENT_ID)
+	require.Equal(t, "# Billing\n", response.RESULTS[2].MARKDOWN)
+	require.Equal(t, []string{"# Billing\n", "Handles invoices\n", "> Ask the platform team\n"}, getRenderedReadme(t, router, "1"))
+
+	// the same checks as the single element endpoints
+	for operation, message := range map[string]string{
+		`{"operation": "add_paragraph", "paragraph": " "}`:                                                                "paragraph cannot be empty",
+		`{"operation": "add_blockquote"}`:                                                                                 "add_blockquote needs its body in blockquote",
+		`{"operation": "update", "element_id": "` + first + `"}`:                                                          "update needs its body in element",
+		`{"operation": "update", "element_id": "` + first + `", "element": {"type": "header", "level": 9, "value": "x"}}`: "header level should be between 1 and 6",
+	} {
+		r := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/readme/1/batch", bytes.NewBufferString(`{"operations": [`+operation+`]}`))
+		router.ServeHTTP(r, req)
+
+		require.Equal(t, http.StatusBadRequest, r.Code, operation)
+		var response BatchResponse
+		require.NoError(t, json.Unmarshal(r.Body.Bytes(), &response))
+		require.Equal(t, message, response.RESULTS[0].ERROR)
+	}
+}
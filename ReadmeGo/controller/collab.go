@@ -0,0 +1,440 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// sessionBuffer is how many messages a client can fall behind before it is disconnected
+	sessionBuffer = 64
+	sessionPing   = 30 * time.Second
+	sessionWait   = 60 * time.Second
+	// sessionMaxMessage is the largest operation a client can send, in bytes
+	sessionMaxMessage = 1 << 20
+)
+
+// sessionRequestKey holds the request id of the session operation being applied in the gin context,
+// the applied message of the change carries it back to the client that sent it
+const sessionRequestKey = "sessionRequestId"
+
+var upgrader = websocket.Upgrader{}
+
+// SessionOperation is sent by a client to change the readme. OPERATION is add, update, delete or move.
+// BASE_REVISION is the last revision the client has seen. Updating or deleting an element someone else changed
+// after it, or touching one they deleted, is refused as a conflict. Leaving it out means the last write wins.
+type SessionOperation struct {
+	REQUEST_ID    string             `json:"request_id"`
+	OPERATION     string             `json:"operation" binding:"required"`
+	BASE_REVISION int                `json:"base_revision,omitempty"`
+	ELEMENT_ID    string             `json:"element_id,omitempty"`
+	ELEMENT       *Element           `json:"element,omitempty"`
+	POSITION      MoveElementRequest `json:"position"`
+}
+
+// SessionMessage is sent to clients. TYPE is joined with the current ELEMENTS when a client connects,
+// applied to everyone for every change to the readme in the order they were made, conflict and error
+// to the client whose operation was refused, or deleted when the readme is deleted and the session ends.
+// REQUEST_ID is only set on the applied message of an operation sent in the session.
+type SessionMessage struct {
+	TYPE       string        `json:"type"`
+	REQUEST_ID string        `json:"request_id,omitempty"`
+	AUTHOR     string        `json:"author,omitempty"`
+	REVISION   int           `json:"revision,omitempty"`
+	OPERATION  string        `json:"operation,omitempty"`
+	CHANGES    []ReadmeEvent `json:"changes,omitempty"`
+	ELEMENTS   []Element     `json:"elements,omitempty"`
+	ELEMENT    *Element      `json:"element,omitempty"`
+	MESSAGE    string        `json:"message,omitempty"`
+}
+
+// conflictError is returned when an operation targets an element changed since the client last saw it
+type conflictError struct {
+	message string
+	// element is the element as it is now, nil if it was deleted
+	element *Element
+}
+
+func (e conflictError) Error() string {
+	return e.message
+}
+
+// sessionClient is one websocket connection in a session
+type sessionClient struct {
+	conn *websocket.Conn
+	send chan SessionMessage
+}
+
+// collabSession is everyone editing one readme. Every change to the readme, from the session or from
+// any other endpoint, is broadcast while the readme's sequence is held so every client sees them in the same order.
+type collabSession struct {
+	mu      sync.Mutex
+	clients map[*sessionClient]bool
+}
+
+// collabSessions holds the open session of every readme someone is editing
+type collabSessions struct {
+	mu       sync.Mutex
+	sessions map[string]*collabSession
+}
+
+func newCollabSessions() *collabSessions {
+	return &collabSessions{sessions: make(map[string]*collabSession)}
+}
+
+// join adds the client to the session of the readme, starting one if needed
+func (s *collabSessions) join(readmeId string, client *sessionClient) *collabSession {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session := s.sessions[readmeId]
+	if session == nil {
+		session = &collabSession{clients: make(map[*sessionClient]bool)}
+		s.sessions[readmeId] = session
+	}
+
+	session.mu.Lock()
+	session.clients[client] = true
+	session.mu.Unlock()
+
+	return session
+}
+
+// leave removes the client and ends the session when it was the last one
+func (s *collabSessions) leave(readmeId string, session *collabSession, client *sessionClient) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session.mu.Lock()
+	session.drop(client)
+	empty := len(session.clients) == 0
+	session.mu.Unlock()
+
+	if empty && s.sessions[readmeId] == session {
+		delete(s.sessions, readmeId)
+	}
+}
+
+// broadcast sends the message to everyone in the session of the readme, if anyone is editing it.
+// Callers hold the readme's sequence so no client joins or leaves with the message half sent.
+func (s *collabSessions) broadcast(readmeId string, message SessionMessage) {
+	s.mu.Lock()
+	session := s.sessions[readmeId]
+	s.mu.Unlock()
+
+	if session == nil {
+		return
+	}
+
+	session.mu.Lock()
+	session.broadcast(message)
+	session.mu.Unlock()
+}
+
+// end sends a last message and disconnects everyone in the session of the readme
+func (s *collabSessions) end(readmeId string, last SessionMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session := s.sessions[readmeId]
+	if session == nil {
+		return
+	}
+
+	session.mu.Lock()
+	session.broadcast(last)
+	for client := range session.clients {
+		session.drop(client)
+	}
+	session.mu.Unlock()
+
+	delete(s.sessions, readmeId)
+}
+
+// drop closes the client's send channel once, callers hold mu
+func (s *collabSession) drop(client *sessionClient) {
+	if s.clients[client] {
+		delete(s.clients, client)
+		close(client.send)
+	}
+}
+
+// broadcast sends the message to every client, clients too far behind are disconnected. Callers hold mu.
+func (s *collabSession) broadcast(message SessionMessage) {
+	for client := range s.clients {
+		s.send(client, message)
+	}
+}
+
+// send queues the message for one client, callers hold mu
+func (s *collabSession) send(client *sessionClient, message SessionMessage) {
+	if !s.clients[client] {
+		return
+	}
+
+	select {
+	case client.send <- message:
+	default:
+		s.drop(client)
+	}
+}
+
+// checkConflict refuses to touch an element that was changed or deleted after the base revision
+func checkConflict(readme *Readme, base int, elementId string) error {
+	if base == 0 {
+		return nil
+	}
+
+	revision, err := readme.revision(base)
+	if err != nil {
+		return err
+	}
+
+	seen := Readme{ELEMENTS: revision.ELEMENTS}
+	seenIndex, err := seen.indexOf(elementId)
+	if err != nil {
+		// added after the base revision, nothing could have changed it unseen
+		return nil
+	}
+
+	index, err := readme.indexOf(elementId)
+	if err != nil {
+		return conflictError{message: "element was deleted after revision " + strconv.Itoa(base)}
+	}
+
+	if !sameContent(seen.ELEMENTS[seenIndex], readme.ELEMENTS[index]) {
+		current := readme.ELEMENTS[index].clone()
+		return conflictError{message: "element was changed after revision " + strconv.Itoa(base), element: &current}
+	}
+
+	return nil
+}
+
+// sessionChange returns the store operation name and the change for a session operation
+func sessionChange(operation SessionOperation) (string, func(readme *Readme) error, error) {
+	switch operation.OPERATION {
+	case "add":
+		if operation.ELEMENT == nil {
+			return "", nil, invalidRequestError{"add needs an element"}
+		}
+		if err := validateElement(*operation.ELEMENT); err != nil {
+			return "", nil, err
+		}
+		if operation.POSITION.count() > 1 {
+			return "", nil, invalidRequestError{"only one of before, after or index can be set"}
+		}
+
+		element := operation.ELEMENT.clone()
+		element.ID = uuid.NewString()
+		return "add " + string(element.TYPE), func(readme *Readme) error {
+			index, err := operation.POSITION.index(readme)
+			if err != nil {
+				return err
+			}
+
+			readme.insertAt(index, element)
+			return nil
+		}, nil
+	case "update":
+		if operation.ELEMENT == nil {
+			return "", nil, invalidRequestError{"update needs an element"}
+		}
+		if err := validateElement(*operation.ELEMENT); err != nil {
+			return "", nil, err
+		}
+
+		element := operation.ELEMENT.clone()
+		element.ID = operation.ELEMENT_ID
+		return "update " + operation.ELEMENT_ID, func(readme *Readme) error {
+			if err := checkConflict(readme, operation.BASE_REVISION, operation.ELEMENT_ID); err != nil {
+				return err
+			}
+
+			index, err := readme.indexOf(operation.ELEMENT_ID)
+			if err != nil {
+				return err
+			}
+
+			readme.ELEMENTS[index] = element
+			return nil
+		}, nil
+	case "delete":
+		return "delete " + operation.ELEMENT_ID, func(readme *Readme) error {
+			if err := checkConflict(readme, operation.BASE_REVISION, operation.ELEMENT_ID); err != nil {
+				return err
+			}
+
+			index, err := readme.indexOf(operation.ELEMENT_ID)
+			if err != nil {
+				return err
+			}
+
+			readme.ELEMENTS = append(readme.ELEMENTS[:index], readme.ELEMENTS[index+1:]...)
+			return nil
+		}, nil
+	case "move":
+		return "move " + operation.ELEMENT_ID, func(readme *Readme) error {
+			if err := checkConflict(readme, operation.BASE_REVISION, operation.ELEMENT_ID); err != nil {
+				var conflict conflictError
+				// moving an element someone else edited keeps their edit, only a deleted element is a conflict
+				if !errors.As(err, &conflict) || conflict.element == nil {
+					return err
+				}
+			}
+
+			return moveElement(readme, operation.ELEMENT_ID, operation.POSITION)
+		}, nil
+	}
+
+	return "", nil, invalidRequestError{"operation should be add, update, delete or move"}
+}
+
+// apply runs a client's operation, the change broadcasts the result to everyone. When the operation
+// is refused only that client is told why.
+func (rc *readmeController) apply(c *gin.Context, session *collabSession, client *sessionClient, operation SessionOperation) {
+	name, fn, err := sessionChange(operation)
+	if err == nil {
+		c.Set(sessionRequestKey, operation.REQUEST_ID)
+		_, err = rc.update(c, c.Param("id"), name, fn)
+	}
+	if err == nil {
+		return
+	}
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	var conflict conflictError
+	if errors.As(err, &conflict) {
+		session.send(client, SessionMessage{TYPE: "conflict", REQUEST_ID: operation.REQUEST_ID, MESSAGE: conflict.message, ELEMENT: conflict.element})
+		return
+	}
+
+	session.send(client, SessionMessage{TYPE: "error", REQUEST_ID: operation.REQUEST_ID, MESSAGE: sessionErrorMessage(err)})
+}
+
+// sessionErrorMessage is the message respondStoreError would have sent for the error
+func sessionErrorMessage(err error) string {
+	var invalidRequest invalidRequestError
+	if errors.As(err, &invalidRequest) {
+		return invalidRequest.message
+	}
+
+	return err.Error()
+}
+
+// writeMessages sends the client's queued messages and keeps the connection alive until the queue is closed
+func writeMessages(client *sessionClient) {
+	ping := time.NewTicker(sessionPing)
+	defer ping.Stop()
+
+	for {
+		select {
+		case message, ok := <-client.send:
+			if !ok {
+				client.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				client.conn.Close()
+				return
+			}
+
+			client.conn.SetWriteDeadline(time.Now().Add(sessionWait))
+			if err := client.conn.WriteJSON(message); err != nil {
+				client.conn.Close()
+				return
+			}
+		case <-ping.C:
+			client.conn.SetWriteDeadline(time.Now().Add(sessionWait))
+			if err := client.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				client.conn.Close()
+				return
+			}
+		}
+	}
+}
+
+// CollabSession godoc
+// @Summary Joins a collaborative editing session
+// @Description Upgrades to a websocket. The client sends SessionOperation messages and receives SessionMessage messages,
+// @Description starting with joined and the current elements. Every change to the readme, whether made in the session or
+// @Description through any other endpoint, is broadcast to everyone in the session in the order it was made.
+// @Description Browsers can't set headers on websockets so author can be a query param.
+// @Param	id	path	string	true	"readme id"
+// @Param	author	query	string	false	"who makes the changes"
+// @Param	X-Author	header	string	false	"who makes the changes"
+// @Success 101	{object}	SessionMessage	"the session messages"
+// @Failure 404	{object}	HttpErrorMessage	"could not find readme"
+// @Router 	/readme/{id}/session	[get]
+func (rc *readmeController) collabSession(c *gin.Context) {
+	readmeId := c.Param("id")
+
+	if _, err := rc.store.Get(readmeId); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	if c.GetHeader("X-Author") == "" && c.Query("author") != "" {
+		c.Request.Header.Set("X-Author", c.Query("author"))
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// the upgrader has already responded
+		return
+	}
+
+	client := &sessionClient{conn: conn, send: make(chan SessionMessage, sessionBuffer)}
+	go writeMessages(client)
+
+	// join and read while holding the sequence so no change lands between the snapshot and the first broadcast
+	unlock := rc.sequence.Lock(readmeId)
+	session := rc.sessions.join(readmeId, client)
+	defer rc.sessions.leave(readmeId, session, client)
+
+	readme, err := rc.store.Get(readmeId)
+	session.mu.Lock()
+	if err != nil {
+		session.send(client, SessionMessage{TYPE: "error", MESSAGE: sessionErrorMessage(err)})
+		session.mu.Unlock()
+		unlock()
+		return
+	}
+	revision := 0
+	if len(readme.REVISIONS) > 0 {
+		revision = readme.REVISIONS[len(readme.REVISIONS)-1].NUMBER
+	}
+	session.send(client, SessionMessage{TYPE: "joined", REVISION: revision, ELEMENTS: readme.ELEMENTS})
+	session.mu.Unlock()
+	unlock()
+
+	conn.SetReadLimit(sessionMaxMessage)
+	conn.SetReadDeadline(time.Now().Add(sessionWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(sessionWait))
+	})
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(sessionWait))
+
+		var operation SessionOperation
+		if err := json.Unmarshal(data, &operation); err != nil {
+			// a message that isn't an operation is reported and the session goes on
+			session.mu.Lock()
+			session.send(client, SessionMessage{TYPE: "error", MESSAGE: "incorrect message, should be SessionOperation body"})
+			session.mu.Unlock()
+			continue
+		}
+
+		rc.apply(c, session, client, operation)
+	}
+}
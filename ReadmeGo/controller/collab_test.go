@@ -0,0 +1,190 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func joinSession(t *testing.T, server *httptest.Server, readmeId string, author string) (*websocket.Conn, SessionMessage) {
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/readme/" + readmeId + "/session?author=" + author
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	joined := readSessionMessage(t, conn)
+	require.Equal(t, "joined", joined.TYPE)
+	return conn, joined
+}
+
+func readSessionMessage(t *testing.T, conn *websocket.Conn) SessionMessage {
+	var message SessionMessage
+	require.NoError(t, conn.ReadJSON(&message))
+	return message
+}
+
+func TestCollabSessionBroadcastsOperations(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/readme?name=onboarding", nil)
+	router.ServeHTTP(w, req)
+	first := addParagraph(t, router, "onboarding", "first")
+
+	alice, joined := joinSession(t, server, "onboarding", "alice")
+	require.Equal(t, 2, joined.REVISION)
+	require.Len(t, joined.ELEMENTS, 1)
+	bob, _ := joinSession(t, server, "onboarding", "bob")
+
+	require.NoError(t, alice.WriteJSON(SessionOperation{REQUEST_ID: "a1", OPERATION: "add", ELEMENT: &Element{TYPE: ParagraphElement, VALUE: "second"}}))
+	require.NoError(t, bob.WriteJSON(SessionOperation{REQUEST_ID: "b1", OPERATION: "move", ELEMENT_ID: first, POSITION: MoveElementRequest{INDEX: new(int)}}))
+
+	// both clients see both operations in the same order
+	aliceSeen := []SessionMessage{readSessionMessage(t, alice), readSessionMessage(t, alice)}
+	bobSeen := []SessionMessage{readSessionMessage(t, bob), readSessionMessage(t, bob)}
+	require.Equal(t, aliceSeen, bobSeen)
+
+	for _, message := range aliceSeen {
+		require.Equal(t, "applied", message.TYPE)
+		switch message.REQUEST_ID {
+		case "a1":
+			require.Equal(t, "alice", message.AUTHOR)
+			require.Equal(t, "add paragraph", message.OPERATION)
+			require.Equal(t, "added", message.CHANGES[0].CHANGE)
+			require.Equal(t, "second\n", message.CHANGES[0].MARKDOWN)
+		case "b1":
+			require.Equal(t, "bob", message.AUTHOR)
+		default:
+			t.Fatalf("unexpected request %s", message.REQUEST_ID)
+		}
+	}
+	require.Equal(t, aliceSeen[0].REVISION+1, aliceSeen[1].REVISION)
+}
+
+func TestCollabSessionRefusesConflictingEdits(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/readme?name=onboarding", nil)
+	router.ServeHTTP(w, req)
+	first := addParagraph(t, router, "onboarding", "first")
+
+	alice, joined := joinSession(t, server, "onboarding", "alice")
+	bob, _ := joinSession(t, server, "onboarding", "bob")
+
+	require.NoError(t, alice.WriteJSON(SessionOperation{REQUEST_ID: "a1", OPERATION: "update", BASE_REVISION: joined.REVISION, ELEMENT_ID: first, ELEMENT: &Element{TYPE: ParagraphElement, VALUE: "alice"}}))
+	require.Equal(t, "applied", readSessionMessage(t, alice).TYPE)
+	require.Equal(t, "applied", readSessionMessage(t, bob).TYPE)
+
+	// bob hasn't seen alice's edit yet
+	require.NoError(t, bob.WriteJSON(SessionOperation{REQUEST_ID: "b1", OPERATION: "update", BASE_REVISION: joined.REVISION, ELEMENT_ID: first, ELEMENT: &Element{TYPE: ParagraphElement, VALUE: "bob"}}))
+
+	conflict := readSessionMessage(t, bob)
+	require.Equal(t, "conflict", conflict.TYPE)
+	require.Equal(t, "b1", conflict.REQUEST_ID)
+	require.Equal(t, "alice", conflict.ELEMENT.VALUE)
+
+	// moving keeps alice's edit so it isn't a conflict
+	require.NoError(t, bob.WriteJSON(SessionOperation{REQUEST_ID: "b2", OPERATION: "move", BASE_REVISION: joined.REVISION, ELEMENT_ID: first, POSITION: MoveElementRequest{INDEX: new(int)}}))
+	require.Equal(t, "applied", readSessionMessage(t, bob).TYPE)
+
+	require.NoError(t, bob.WriteJSON(SessionOperation{REQUEST_ID: "b3", OPERATION: "rename"}))
+	invalid := readSessionMessage(t, bob)
+	require.Equal(t, "error", invalid.TYPE)
+	require.Equal(t, "operation should be add, update, delete or move", invalid.MESSAGE)
+
+	require.NoError(t, bob.WriteMessage(websocket.TextMessage, []byte("not json")))
+	require.Equal(t, "error", readSessionMessage(t, bob).TYPE)
+
+	require.Equal(t, []string{"alice\n"}, getRenderedReadme(t, router, "onboarding"))
+}
+
+func TestCollabSessionRelaysChangesFromOtherEndpoints(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	w := httptest.NewRecorder()
+	req1, _ := http.NewRequest("POST", "/readme?name=onboarding", nil)
+	router.ServeHTTP(w, req1)
+
+	alice, joined := joinSession(t, server, "onboarding", "alice")
+
+	second := addParagraph(t, router, "onboarding", "from rest")
+
+	applied := readSessionMessage(t, alice)
+	require.Equal(t, "applied", applied.TYPE)
+	require.Empty(t, applied.REQUEST_ID)
+	require.Equal(t, joined.REVISION+1, applied.REVISION)
+	require.Equal(t, "add paragraph", applied.OPERATION)
+	require.Equal(t, second, applied.CHANGES[0].ELEMENT_ID)
+	require.Equal(t, "from rest\n", applied.CHANGES[0].MARKDOWN)
+
+	req2, _ := http.NewRequest("POST", "/readme/onboarding/undo", nil)
+	req2.Header.Set("X-Author", "bob")
+	router.ServeHTTP(w, req2)
+
+	undone := readSessionMessage(t, alice)
+	require.Equal(t, "applied", undone.TYPE)
+	require.Equal(t, "bob", undone.AUTHOR)
+	require.Equal(t, "undo", undone.OPERATION)
+	require.Equal(t, "removed", undone.CHANGES[0].CHANGE)
+
+	// alice's edit based on what she was sent conflicts with nothing
+	require.NoError(t, alice.WriteJSON(SessionOperation{REQUEST_ID: "a1", OPERATION: "add", BASE_REVISION: undone.REVISION, ELEMENT: &Element{TYPE: ParagraphElement, VALUE: "from alice"}}))
+	mine := readSessionMessage(t, alice)
+	require.Equal(t, "applied", mine.TYPE)
+	require.Equal(t, "a1", mine.REQUEST_ID)
+	require.Equal(t, undone.REVISION+1, mine.REVISION)
+
+	req3, _ := http.NewRequest("DELETE", "/readme/onboarding", nil)
+	router.ServeHTTP(w, req3)
+
+	require.Equal(t, "deleted", readSessionMessage(t, alice).TYPE)
+	_, _, err := alice.ReadMessage()
+	require.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure), err)
+}
+
+func TestCollabSessionChecksIncludedSnippets(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/readme?name=onboarding", nil)
+	router.ServeHTTP(w, req)
+	createSnippet(t, router, `{"name": "license", "elements": [{"type": "paragraph", "value": "Apache 2.0"}]}`)
+	first := addParagraph(t, router, "onboarding", "first")
+
+	alice, _ := joinSession(t, server, "onboarding", "alice")
+
+	require.NoError(t, alice.WriteJSON(SessionOperation{REQUEST_ID: "a1", OPERATION: "add", ELEMENT: &Element{TYPE: SnippetElement, SNIPPET: "ghost"}}))
+	refused := readSessionMessage(t, alice)
+	require.Equal(t, "error", refused.TYPE)
+	require.Equal(t, "missing snippets: ghost", refused.MESSAGE)
+
+	require.NoError(t, alice.WriteJSON(SessionOperation{REQUEST_ID: "a2", OPERATION: "update", ELEMENT_ID: first, ELEMENT: &Element{TYPE: SnippetElement, SNIPPET: "license"}}))
+	applied := readSessionMessage(t, alice)
+	require.Equal(t, "applied", applied.TYPE)
+	require.Equal(t, "a2", applied.REQUEST_ID)
+
+	require.Equal(t, []string{"Apache 2.0\n"}, getRenderedReadme(t, router, "onboarding"))
+}
+
+func TestCollabSessionReturnsReadmeNotFound(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	_, response, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/readme/INVALID/session", nil)
+	require.Error(t, err)
+	require.Equal(t, http.StatusNotFound, response.StatusCode)
+}
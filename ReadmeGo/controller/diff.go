@@ -0,0 +1,215 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+type DiffResponse struct {
+	FROM     string        `json:"from" binding:"required"`
+	TO       string        `json:"to" binding:"required"`
+	UNIFIED  string        `json:"unified" binding:"required"`
+	ELEMENTS []ElementDiff `json:"elements" binding:"required"`
+}
+
+// ElementDiff is one structural change, OPERATION is added, removed, modified or moved
+type ElementDiff struct {
+	OPERATION  string   `json:"operation" binding:"required"`
+	ELEMENT_ID string   `json:"element_id" binding:"required"`
+	FROM_INDEX *int     `json:"from_index,omitempty"`
+	TO_INDEX   *int     `json:"to_index,omitempty"`
+	BEFORE     *Element `json:"before,omitempty"`
+	AFTER      *Element `json:"after,omitempty"`
+}
+
+// diffSide is one of the two readme versions being compared
+type diffSide struct {
+	label    string
+	elements []Element
+}
+
+// loadDiffSide returns the elements of the readme at the revision, or the current elements when revision is empty
+func (rc *readmeController) loadDiffSide(readmeId string, revision string) (diffSide, error) {
+	readme, err := rc.store.Get(readmeId)
+	if err != nil {
+		return diffSide{}, err
+	}
+
+	if revision == "" {
+		return diffSide{label: readmeId, elements: readme.ELEMENTS}, nil
+	}
+
+	number, err := strconv.Atoi(revision)
+	if err != nil {
+		return diffSide{}, invalidRequestError{"revision should be a number"}
+	}
+
+	found, err := readme.revision(number)
+	if err != nil {
+		return diffSide{}, err
+	}
+
+	return diffSide{label: readmeId + "@" + revision, elements: found.ELEMENTS}, nil
+}
+
+// DiffReadme godoc
+// @Summary Diffs two versions of readmes
+// @Description Compares the readme at revision from with the readme named by against (the same readme by default) at revision to.
+// @Description Leaving out a revision uses the current readme. Returns a unified diff of the markdown and the element changes.
+// @Description Both are of the stored elements: snippets show as their placeholder comment and variables as {{.Name}}, so
+// @Description the diff of a revision doesn't depend on the snippets and variables of today.
+// @Accept json
+// @Produce json
+// @Param	id	path	string	true	"readme id"
+// @Param	from	query	int	false	"revision of the readme to compare from"
+// @Param	against	query	string	false	"readme id to compare to"
+// @Param	to	query	int	false	"revision of the against readme to compare to"
+// @Success	200	{object}	DiffResponse	"unified and structural diff"
+// @Failure 404	{object}	HttpErrorMessage	"could not find readme or revision"
+// @Failure 400	{object}	HttpErrorMessage	"revision should be a number"
+// @Router	/readme/{id}/diff	[get]
+func (rc *readmeController) diffReadme(c *gin.Context) {
+	readmeId := c.Param("id")
+	against := c.DefaultQuery("against", readmeId)
+
+	from, err := rc.loadDiffSide(readmeId, c.Query("from"))
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	to, err := rc.loadDiffSide(against, c.Query("to"))
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	unified, err := unifiedDiff(from, to)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, DiffResponse{
+		FROM:     from.label,
+		TO:       to.label,
+		UNIFIED:  unified,
+		ELEMENTS: diffElements(from.elements, to.elements),
+	})
+}
+
+func unifiedDiff(from diffSide, to diffSide) (string, error) {
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        markdownLines(from.elements),
+		B:        markdownLines(to.elements),
+		FromFile: from.label,
+		ToFile:   to.label,
+		Context:  3,
+	})
+}
+
+// markdownLines splits the rendered elements into lines that all end in a newline, difflib.SplitLines
+// would add an empty last line to markdown that already ends in one. The elements are rendered as they
+// are stored, not through rc.render, since revisions don't keep the snippets and variables they used.
+func markdownLines(elements []Element) []string {
+	markdown := renderReadme(Readme{ELEMENTS: elements})
+	if markdown == "" {
+		return []string{}
+	}
+
+	if !strings.HasSuffix(markdown, "\n") {
+		markdown += "\n"
+	}
+
+	lines := strings.SplitAfter(markdown, "\n")
+	return lines[:len(lines)-1]
+}
+
+// sameContent compares elements ignoring their ids
+func sameContent(a Element, b Element) bool {
+	a.ID, b.ID = "", ""
+	return reflect.DeepEqual(a, b)
+}
+
+// diffElements pairs up elements by id, elements of different readmes never share ids so any left
+// over are paired with an element of the same content. Paired elements that are no longer in the
+// same order relative to each other are moved, unpaired ones were added or removed.
+func diffElements(from []Element, to []Element) []ElementDiff {
+	pairs := make(map[int]int)
+	paired := make(map[int]bool)
+
+	toIndex := make(map[string]int)
+	for i, element := range to {
+		toIndex[element.ID] = i
+	}
+	for i, element := range from {
+		if j, ok := toIndex[element.ID]; ok && !paired[j] {
+			pairs[i] = j
+			paired[j] = true
+		}
+	}
+
+	for i, element := range from {
+		if _, ok := pairs[i]; ok {
+			continue
+		}
+		for j := range to {
+			if !paired[j] && sameContent(element, to[j]) {
+				pairs[i] = j
+				paired[j] = true
+				break
+			}
+		}
+	}
+
+	// the paired elements in from order and in to order, anything outside the longest common run moved
+	fromOrder, toOrder := []string{}, make([]string, 0, len(pairs))
+	for i := range from {
+		if j, ok := pairs[i]; ok {
+			fromOrder = append(fromOrder, strconv.Itoa(j))
+		}
+	}
+	for j := range to {
+		if paired[j] {
+			toOrder = append(toOrder, strconv.Itoa(j))
+		}
+	}
+
+	stayed := make(map[string]bool)
+	for _, block := range difflib.NewMatcher(fromOrder, toOrder).GetMatchingBlocks() {
+		for k := 0; k < block.Size; k++ {
+			stayed[fromOrder[block.A+k]] = true
+		}
+	}
+
+	diffs := []ElementDiff{}
+	for i := range from {
+		i := i
+		j, ok := pairs[i]
+		if !ok {
+			diffs = append(diffs, ElementDiff{OPERATION: "removed", ELEMENT_ID: from[i].ID, FROM_INDEX: &i, BEFORE: &from[i]})
+			continue
+		}
+
+		if !sameContent(from[i], to[j]) {
+			diffs = append(diffs, ElementDiff{OPERATION: "modified", ELEMENT_ID: to[j].ID, FROM_INDEX: &i, TO_INDEX: &j, BEFORE: &from[i], AFTER: &to[j]})
+		}
+		if !stayed[strconv.Itoa(j)] {
+			diffs = append(diffs, ElementDiff{OPERATION: "moved", ELEMENT_ID: to[j].ID, FROM_INDEX: &i, TO_INDEX: &j})
+		}
+	}
+
+	for j := range to {
+		j := j
+		if !paired[j] {
+			diffs = append(diffs, ElementDiff{OPERATION: "added", ELEMENT_ID: to[j].ID, TO_INDEX: &j, AFTER: &to[j]})
+		}
+	}
+
+	return diffs
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffElements(t *testing.T) {
+	a := Element{ID: "a", TYPE: ParagraphElement, VALUE: "a"}
+	b := Element{ID: "b", TYPE: ParagraphElement, VALUE: "b"}
+	c := Element{ID: "c", TYPE: ParagraphElement, VALUE: "c"}
+	changedB := Element{ID: "b", TYPE: HeaderElement, LEVEL: 1, VALUE: "b"}
+	d := Element{ID: "d", TYPE: ParagraphElement, VALUE: "d"}
+
+	diffs := diffElements([]Element{a, b, c}, []Element{c, a, changedB, d})
+
+	operations := map[string]string{}
+	for _, diff := range diffs {
+		operations[diff.OPERATION] = operations[diff.OPERATION] + diff.ELEMENT_ID
+	}
+	require.Equal(t, map[string]string{"moved": "c", "modified": "b", "added": "d"}, operations)
+}
+
+func TestDiffElementsPairsElementsOfOtherReadmesByContent(t *testing.T) {
+	from := []Element{{ID: "1", TYPE: ParagraphElement, VALUE: "same"}, {ID: "2", TYPE: ParagraphElement, VALUE: "old"}}
+	to := []Element{{ID: "x", TYPE: ParagraphElement, VALUE: "same"}, {ID: "y", TYPE: ParagraphElement, VALUE: "new"}}
+
+	diffs := diffElements(from, to)
+
+	require.Len(t, diffs, 2)
+	require.Equal(t, "removed", diffs[0].OPERATION)
+	require.Equal(t, "2", diffs[0].ELEMENT_ID)
+	require.Equal(t, "added", diffs[1].OPERATION)
+	require.Equal(t, "y", diffs[1].ELEMENT_ID)
+}
+
+func TestDiffReadmeRevisions(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+	r := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+	router.ServeHTTP(w, req1)
+
+	elementId := addParagraph(t, router, "1", "Instal")
+	addParagraph(t, router, "1", "usage")
+
+	req2, _ := http.NewRequest("PUT", "/readme/1/element/"+elementId, bytes.NewBufferString(`{"type": "paragraph", "value": "Install"}`))
+	router.ServeHTTP(w, req2)
+
+	req3, _ := http.NewRequest("GET", "/readme/1/diff?from=3", nil)
+	router.ServeHTTP(r, req3)
+
+	var diff DiffResponse
+	require.NoError(t, json.Unmarshal(r.Body.Bytes(), &diff))
+	require.Equal(t, "1@3", diff.FROM)
+	require.Equal(t, "1", diff.TO)
+	require.Equal(t, "--- 1@3\n+++ 1\n@@ -1,2 +1,2 @@\n-Instal\n+Install\n usage\n", diff.UNIFIED)
+	require.Len(t, diff.ELEMENTS, 1)
+	require.Equal(t, "modified", diff.ELEMENTS[0].OPERATION)
+	require.Equal(t, elementId, diff.ELEMENTS[0].ELEMENT_ID)
+	require.Equal(t, "Instal", diff.ELEMENTS[0].BEFORE.VALUE)
+	require.Equal(t, "Install", diff.ELEMENTS[0].AFTER.VALUE)
+}
+
+func TestDiffTwoReadmes(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+	r := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme?name=billing", nil)
+	router.ServeHTTP(w, req1)
+	req2, _ := http.NewRequest("POST", "/readme?name=payments", nil)
+	router.ServeHTTP(w, req2)
+
+	addParagraph(t, router, "billing", "shared")
+	addParagraph(t, router, "payments", "shared")
+	addParagraph(t, router, "payments", "extra")
+
+	req3, _ := http.NewRequest("GET", "/readme/billing/diff?against=payments", nil)
+	router.ServeHTTP(r, req3)
+
+	var diff DiffResponse
+	require.NoError(t, json.Unmarshal(r.Body.Bytes(), &diff))
+	require.Equal(t, "--- billing\n+++ payments\n@@ -1 +1,2 @@\n shared\n+extra\n", diff.UNIFIED)
+	require.Len(t, diff.ELEMENTS, 1)
+	require.Equal(t, "added", diff.ELEMENTS[0].OPERATION)
+}
+
+func TestDiffReadmeReturnsRevisionNotFound(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+	router.ServeHTTP(w, req1)
+
+	for query, code := range map[string]int{"from=9": http.StatusNotFound, "to=first": http.StatusBadRequest, "against=INVALID": http.StatusNotFound} {
+		r := httptest.NewRecorder()
+		req2, _ := http.NewRequest("GET", "/readme/1/diff?"+query, nil)
+		router.ServeHTTP(r, req2)
+
+		require.Equal(t, code, r.Code, query)
+	}
+}
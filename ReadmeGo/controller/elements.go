@@ -0,0 +1,281 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+var ErrElementNotFound = errors.New("could not find element")
+
+type ElementType string
+
+const (
+	HeaderElement     ElementType = "header"
+	ParagraphElement  ElementType = "paragraph"
+	CodeElement       ElementType = "code"
+	BlockquoteElement ElementType = "blockquote"
+	LinkElement       ElementType = "link"
+	ImageElement      ElementType = "image"
+	TableElement      ElementType = "table"
+	ListElement       ElementType = "list"
+	TaskListElement   ElementType = "tasklist"
+	// RawElement is markdown that is written out exactly as stored, readmes saved before
+	// elements were typed are loaded as raw elements
+	RawElement ElementType = "raw"
+	// SnippetElement stands in for the elements of a shared snippet, they are filled in when the readme is rendered
+	SnippetElement ElementType = "snippet"
+)
+
+var headingLevelMap = map[string]int{
+	"SMALL_HEADING":  3,
+	"MEDIUM_HEADING": 2,
+	"LARGE_HEADING":  1,
+}
+
+// Element is one typed markdown element of a readme, only the fields used by its TYPE are set
+type Element struct {
+	ID            string              `json:"id,omitempty"`
+	TYPE          ElementType         `json:"type" binding:"required"`
+	LEVEL         int                 `json:"level,omitempty"`
+	VALUE         string              `json:"value,omitempty"`
+	CODE_LANGUAGE string              `json:"code_language,omitempty"`
+	DESCRIPTION   string              `json:"description,omitempty"`
+	LINK          string              `json:"link,omitempty"`
+	COLUMN_NAMES  []string            `json:"column_names,omitempty"`
+	COLUMN_VALUES map[string][]string `json:"column_values,omitempty"`
+	SNIPPET       string              `json:"snippet,omitempty"`
+	LIST          *List               `json:"list,omitempty"`
+}
+
+// Readme is an ordered list of elements, markdown is only produced when it is rendered
+type Readme struct {
+	NAME       string    `json:"name"`
+	CREATED_AT time.Time `json:"created_at"`
+	ELEMENTS   []Element `json:"elements"`
+	// VARIABLES are filled into the elements when the readme is rendered, nil when the readme renders as written
+	VARIABLES map[string]string `json:"variables"`
+	// REVISIONS, UNDO and REDO are kept by the store but never sent back with the readme
+	REVISIONS []Revision  `json:"-"`
+	UNDO      []Operation `json:"-"`
+	REDO      []Operation `json:"-"`
+}
+
+// ReadmeSummary is what listing readmes returns, without the elements
+type ReadmeSummary struct {
+	NAME       string    `json:"name"`
+	CREATED_AT time.Time `json:"created_at"`
+}
+
+func (r Readme) summary() ReadmeSummary {
+	return ReadmeSummary{NAME: r.NAME, CREATED_AT: r.CREATED_AT}
+}
+
+// clone copies the element so the caller can change it without touching stored state
+func (e Element) clone() Element {
+	if e.COLUMN_NAMES != nil {
+		e.COLUMN_NAMES = append([]string{}, e.COLUMN_NAMES...)
+	}
+
+	if e.COLUMN_VALUES != nil {
+		columnValues := make(map[string][]string, len(e.COLUMN_VALUES))
+		for column, values := range e.COLUMN_VALUES {
+			columnValues[column] = append([]string{}, values...)
+		}
+		e.COLUMN_VALUES = columnValues
+	}
+
+	if e.LIST != nil {
+		list := e.LIST.clone()
+		e.LIST = &list
+	}
+
+	return e
+}
+
+func (r Readme) clone() Readme {
+	elements := make([]Element, len(r.ELEMENTS))
+	for i, element := range r.ELEMENTS {
+		elements[i] = element.clone()
+	}
+	r.ELEMENTS = elements
+
+	if r.REVISIONS != nil {
+		revisions := make([]Revision, len(r.REVISIONS))
+		for i, revision := range r.REVISIONS {
+			revision.ELEMENTS = Readme{ELEMENTS: revision.ELEMENTS}.clone().ELEMENTS
+			revisions[i] = revision
+		}
+		r.REVISIONS = revisions
+	}
+
+	r.VARIABLES = cloneVariables(r.VARIABLES)
+	r.UNDO = cloneOperations(r.UNDO)
+	r.REDO = cloneOperations(r.REDO)
+
+	return r
+}
+
+// cloneVariables copies the variables, nil stays nil as it means the readme renders as written
+func cloneVariables(variables map[string]string) map[string]string {
+	if variables == nil {
+		return nil
+	}
+
+	cloned := make(map[string]string, len(variables))
+	for name, value := range variables {
+		cloned[name] = value
+	}
+
+	return cloned
+}
+
+// indexOf returns the position of the element in the readme or ErrElementNotFound
+func (r *Readme) indexOf(elementId string) (int, error) {
+	for i, element := range r.ELEMENTS {
+		if element.ID == elementId {
+			return i, nil
+		}
+	}
+
+	return -1, ErrElementNotFound
+}
+
+// insertAt puts the element at index, shifting everything after it down
+func (r *Readme) insertAt(index int, element Element) {
+	r.ELEMENTS = append(r.ELEMENTS, Element{})
+	copy(r.ELEMENTS[index+1:], r.ELEMENTS[index:])
+	r.ELEMENTS[index] = element
+}
+
+// validateElement checks that the fields used by the element type are filled in
+func validateElement(element Element) error {
+	switch element.TYPE {
+	case HeaderElement:
+		if element.LEVEL < 1 || element.LEVEL > 6 {
+			return invalidRequestError{"header level should be between 1 and 6"}
+		}
+		if strings.TrimSpace(element.VALUE) == "" {
+			return invalidRequestError{"header cannot be empty"}
+		}
+	case ParagraphElement, BlockquoteElement:
+		if strings.TrimSpace(element.VALUE) == "" {
+			return invalidRequestError{string(element.TYPE) + " cannot be empty"}
+		}
+	case CodeElement:
+		if !codeLanguageMap[element.CODE_LANGUAGE] {
+			return invalidRequestError{"Code language not supported"}
+		}
+		if element.VALUE == "" {
+			return invalidRequestError{"code cannot be empty"}
+		}
+	case LinkElement, ImageElement:
+		if element.DESCRIPTION == "" || element.LINK == "" {
+			return invalidRequestError{string(element.TYPE) + " needs a description and a link"}
+		}
+	case TableElement:
+		if len(element.COLUMN_NAMES) == 0 {
+			return invalidRequestError{"table needs at least one column"}
+		}
+	case ListElement, TaskListElement:
+		return validateList(element.LIST, element.TYPE == TaskListElement)
+	case SnippetElement:
+		if !snippetNamePattern.MatchString(element.SNIPPET) {
+			return invalidRequestError{"snippet needs the name of a snippet"}
+		}
+	case RawElement:
+	default:
+		return invalidRequestError{"unknown element type " + string(element.TYPE)}
+	}
+
+	return nil
+}
+
+// renderElement returns the markdown for a single element
+func renderElement(element Element) string {
+	switch element.TYPE {
+	case HeaderElement:
+		headerMarkdown := ""
+		if element.LEVEL > 0 {
+			headerMarkdown = strings.Repeat("#", element.LEVEL) + " "
+		}
+		return headerMarkdown + element.VALUE + "\n"
+	case ParagraphElement:
+		return element.VALUE + "\n"
+	case CodeElement:
+		return "```" + element.CODE_LANGUAGE + "\n" + element.VALUE + "\n```\n"
+	case BlockquoteElement:
+		return "> " + element.VALUE + "\n"
+	case LinkElement:
+		return "[" + element.DESCRIPTION + "]" + "(" + element.LINK + ")" + "\n"
+	case ImageElement:
+		return "![" + element.DESCRIPTION + "]" + "(" + element.LINK + ")" + "\n"
+	case TableElement:
+		return renderTable(element)
+	case ListElement, TaskListElement:
+		var list strings.Builder
+		if element.LIST != nil {
+			renderList(*element.LIST, "", element.TYPE == TaskListElement, &list)
+		}
+		return list.String()
+	case RawElement:
+		return element.VALUE
+	case SnippetElement:
+		// only seen before the snippet is filled in, like in the element endpoints and change events
+		return "<!-- snippet " + element.SNIPPET + " -->\n"
+	}
+
+	return ""
+}
+
+func renderTable(element Element) string {
+	largestColumn := 0
+	createdTableString := `|`
+
+	//create column label row
+	for _, cName := range element.COLUMN_NAMES {
+		if len(element.COLUMN_VALUES[cName]) > largestColumn {
+			largestColumn = len(element.COLUMN_VALUES[cName])
+		}
+		createdTableString = createdTableString + cName + `|`
+	}
+
+	createdTableString = createdTableString + "\n" + `|`
+
+	// create separated between column label and column values
+	for i := 0; i < len(element.COLUMN_NAMES); i++ {
+		createdTableString = createdTableString + ` --- |`
+	}
+
+	createdTableString = createdTableString + "\n"
+
+	// values in each column
+	for i := 0; i < largestColumn; i++ {
+		currentString := `|`
+		for _, column_name := range element.COLUMN_NAMES {
+			if i < len(element.COLUMN_VALUES[column_name]) {
+				currentString = currentString + element.COLUMN_VALUES[column_name][i] + `|`
+			} else {
+				currentString = currentString + " " + `|`
+			}
+		}
+		createdTableString = createdTableString + currentString + "\n"
+	}
+
+	return createdTableString
+}
+
+// renderElements renders every element on its own, in readme order
+func renderElements(elements []Element) []string {
+	rendered := make([]string, len(elements))
+	for i, element := range elements {
+		rendered[i] = renderElement(element)
+	}
+
+	return rendered
+}
+
+// renderReadme returns the markdown document for the whole readme
+func renderReadme(readme Readme) string {
+	return strings.Join(renderElements(readme.ELEMENTS), "")
+}
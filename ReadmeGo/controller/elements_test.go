@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderElement(t *testing.T) {
+	tests := map[string]struct {
+		element  Element
+		markdown string
+	}{
+		"header":     {Element{TYPE: HeaderElement, LEVEL: 2, VALUE: "Install"}, "## Install\n"},
+		"paragraph":  {Element{TYPE: ParagraphElement, VALUE: "Some text"}, "Some text\n"},
+		"code":       {Element{TYPE: CodeElement, CODE_LANGUAGE: "go", VALUE: "go run ."}, "```go\ngo run .\n```\n"},
+		"blockquote": {Element{TYPE: BlockquoteElement, VALUE: "quote"}, "> quote\n"},
+		"link":       {Element{TYPE: LinkElement, DESCRIPTION: "Go Dev", LINK: "https://go.dev/doc/"}, "[Go Dev](https://go.dev/doc/)\n"},
+		"image":      {Element{TYPE: ImageElement, DESCRIPTION: "Metamask", LINK: "https://imgur.com/grhk1rU"}, "![Metamask](https://imgur.com/grhk1rU)\n"},
+		"raw":        {Element{TYPE: RawElement, VALUE: "<br>"}, "<br>"},
+		"table": {
+			Element{TYPE: TableElement, COLUMN_NAMES: []string{"c1", "c2"}, COLUMN_VALUES: map[string][]string{"c1": {"value1", "value2"}, "c2": {"value3"}}},
+			"|c1|c2|\n| --- | --- |\n|value1|value3|\n|value2| |\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.markdown, renderElement(test.element))
+		})
+	}
+}
+
+func TestRenderReadme(t *testing.T) {
+	readme := Readme{NAME: "1", ELEMENTS: []Element{
+		{TYPE: HeaderElement, LEVEL: 1, VALUE: "ReadmeBuilder"},
+		{TYPE: ParagraphElement, VALUE: "Builds markdown files"},
+	}}
+
+	require.Equal(t, "# ReadmeBuilder\nBuilds markdown files\n", renderReadme(readme))
+}
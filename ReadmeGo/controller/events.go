@@ -0,0 +1,155 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// eventBuffer is how many events a subscriber can fall behind before it is dropped
+const eventBuffer = 64
+
+// ReadmeEvent is sent to everyone watching a readme for every element a change touched.
+// CHANGE is added, removed, modified or moved, variables when the variables changed and every element may
+// render differently, or deleted when the readme itself is deleted.
+type ReadmeEvent struct {
+	REVISION   int    `json:"revision,omitempty"`
+	OPERATION  string `json:"operation"`
+	CHANGE     string `json:"change"`
+	ELEMENT_ID string `json:"element_id,omitempty"`
+	INDEX      *int   `json:"index,omitempty"`
+	MARKDOWN   string `json:"markdown,omitempty"`
+}
+
+// readmeEvents fans out change events to the subscribers of each readme
+type readmeEvents struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan ReadmeEvent]bool
+}
+
+func newReadmeEvents() *readmeEvents {
+	return &readmeEvents{subscribers: make(map[string]map[chan ReadmeEvent]bool)}
+}
+
+// Subscribe returns a channel of the events of the readme and the func that stops them.
+// The channel is closed when the subscriber falls too far behind or the readme is deleted.
+func (e *readmeEvents) Subscribe(readmeId string) (<-chan ReadmeEvent, func()) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	events := make(chan ReadmeEvent, eventBuffer)
+	if e.subscribers[readmeId] == nil {
+		e.subscribers[readmeId] = make(map[chan ReadmeEvent]bool)
+	}
+	e.subscribers[readmeId][events] = true
+
+	return events, func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		e.remove(readmeId, events)
+	}
+}
+
+// remove closes the subscriber channel once, callers hold mu
+func (e *readmeEvents) remove(readmeId string, events chan ReadmeEvent) {
+	if !e.subscribers[readmeId][events] {
+		return
+	}
+
+	delete(e.subscribers[readmeId], events)
+	if len(e.subscribers[readmeId]) == 0 {
+		delete(e.subscribers, readmeId)
+	}
+	close(events)
+}
+
+// Publish sends the events to every subscriber of the readme without waiting on slow ones
+func (e *readmeEvents) Publish(readmeId string, published ...ReadmeEvent) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for events := range e.subscribers[readmeId] {
+		for _, event := range published {
+			select {
+			case events <- event:
+			default:
+				e.remove(readmeId, events)
+			}
+			if !e.subscribers[readmeId][events] {
+				break
+			}
+		}
+	}
+}
+
+// Close sends a last event and ends every subscription of the readme
+func (e *readmeEvents) Close(readmeId string, last ReadmeEvent) {
+	e.Publish(readmeId, last)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for events := range e.subscribers[readmeId] {
+		e.remove(readmeId, events)
+	}
+}
+
+// changeEvents describes the difference between the elements before and after a change
+func changeEvents(revision Revision, before []Element, after []Element) []ReadmeEvent {
+	diffs := diffElements(before, after)
+	events := make([]ReadmeEvent, len(diffs))
+	for i, diff := range diffs {
+		event := ReadmeEvent{REVISION: revision.NUMBER, OPERATION: revision.OPERATION, CHANGE: diff.OPERATION, ELEMENT_ID: diff.ELEMENT_ID, INDEX: diff.TO_INDEX}
+		if diff.OPERATION == "removed" {
+			event.INDEX = diff.FROM_INDEX
+		} else {
+			event.MARKDOWN = renderElement(after[*diff.TO_INDEX])
+		}
+		events[i] = event
+	}
+
+	return events
+}
+
+// ReadmeEvents godoc
+// @Summary Streams changes to a readme
+// @Description Server-sent events, one for every element added, removed, modified or moved and one when the variables change.
+// @Description The event name is the change and the data a ReadmeEvent with the rendered markdown of the element.
+// @Description The stream ends when the readme is deleted.
+// @Produce text/event-stream
+// @Param	id	path	string	true	"readme id"
+// @Success 200	{object}	ReadmeEvent	"stream of events"
+// @Failure 404	{object}	HttpErrorMessage	"could not find readme"
+// @Router 	/readme/{id}/events	[get]
+func (rc *readmeController) streamEvents(c *gin.Context) {
+	readmeId := c.Param("id")
+
+	events, stop := rc.events.Subscribe(readmeId)
+	defer stop()
+
+	// subscribed first so nothing that happens after the check is missed
+	if _, err := rc.store.Get(readmeId); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent(event.CHANGE, event)
+			return event.CHANGE != "deleted"
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
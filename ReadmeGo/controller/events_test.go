@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// readEvent reads the next server-sent event from the stream
+func readEvent(t *testing.T, stream *bufio.Reader) (string, ReadmeEvent) {
+	var name string
+	var event ReadmeEvent
+	for {
+		line, err := stream.ReadString('\n')
+		require.NoError(t, err)
+
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			name = line[len("event:"):]
+		case strings.HasPrefix(line, "data:"):
+			require.NoError(t, json.Unmarshal([]byte(line[len("data:"):]), &event))
+		case line == "" && name != "":
+			return name, event
+		}
+	}
+}
+
+func TestStreamEvents(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	w := httptest.NewRecorder()
+	req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+	router.ServeHTTP(w, req1)
+
+	first := addParagraph(t, router, "1", "first")
+
+	response, err := http.Get(server.URL + "/readme/1/events")
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+	stream := bufio.NewReader(response.Body)
+
+	second := addParagraph(t, router, "1", "second")
+
+	name, event := readEvent(t, stream)
+	require.Equal(t, "added", name)
+	require.Equal(t, second, event.ELEMENT_ID)
+	require.Equal(t, "add paragraph", event.OPERATION)
+	require.Equal(t, 1, *event.INDEX)
+	require.Equal(t, "second\n", event.MARKDOWN)
+
+	req2, _ := http.NewRequest("PUT", "/readme/1/element/"+first, bytes.NewBufferString(`{"type": "paragraph", "value": "changed"}`))
+	router.ServeHTTP(w, req2)
+
+	name, event = readEvent(t, stream)
+	require.Equal(t, "modified", name)
+	require.Equal(t, first, event.ELEMENT_ID)
+	require.Equal(t, "changed\n", event.MARKDOWN)
+
+	req3, _ := http.NewRequest("POST", "/readme/1/element/"+first+"/move", bytes.NewBufferString(`{"index": 1}`))
+	router.ServeHTTP(w, req3)
+
+	name, event = readEvent(t, stream)
+	require.Equal(t, "moved", name)
+	require.Equal(t, "move "+first, event.OPERATION)
+
+	req4, _ := http.NewRequest("DELETE", "/readme/1/element/"+second, nil)
+	router.ServeHTTP(w, req4)
+
+	name, event = readEvent(t, stream)
+	require.Equal(t, "removed", name)
+	require.Equal(t, second, event.ELEMENT_ID)
+	require.Equal(t, 0, *event.INDEX)
+
+	req5, _ := http.NewRequest("DELETE", "/readme/1", nil)
+	router.ServeHTTP(w, req5)
+
+	name, _ = readEvent(t, stream)
+	require.Equal(t, "deleted", name)
+
+	_, err = stream.ReadString('\n')
+	require.Error(t, err)
+}
+
+func TestStreamEventsReturnsReadmeNotFound(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	r := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("GET", "/readme/INVALID/events", nil)
+	router.ServeHTTP(r, req)
+
+	require.Equal(t, http.StatusNotFound, r.Code)
+}
+
+func TestSlowSubscriberIsDropped(t *testing.T) {
+	events := newReadmeEvents()
+	slow, stop := events.Subscribe("1")
+	defer stop()
+
+	for i := 0; i <= eventBuffer; i++ {
+		events.Publish("1", ReadmeEvent{CHANGE: "added"})
+	}
+
+	received := 0
+	for range slow {
+		received++
+	}
+	require.Equal(t, eventBuffer, received)
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExportRequest struct {
+	PATH string `json:"path"`
+}
+
+// exporter writes rendered readmes below root, nothing is ever written outside of it
+type exporter struct {
+	root string
+}
+
+// withExportRoot lets readmes be exported to files inside root
+func withExportRoot(root string) func(rc *readmeController) {
+	return func(rc *readmeController) {
+		rc.exporter = &exporter{root: root}
+	}
+}
+
+// resolve checks the relative path stays inside root and returns where it points on disk
+func (e *exporter) resolve(path string) (string, error) {
+	cleaned := filepath.Clean(filepath.FromSlash(path))
+	if path == "" || filepath.IsAbs(cleaned) || filepath.VolumeName(cleaned) != "" ||
+		cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", invalidRequestError{"export path should be a file path relative to the export directory"}
+	}
+
+	return filepath.Join(e.root, cleaned), nil
+}
+
+// inside reports whether path, after following symlinks, is root or below it
+func (e *exporter) inside(path string) (bool, error) {
+	root, err := filepath.EvalSymlinks(e.root)
+	if err != nil {
+		return false, err
+	}
+
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return false, err
+	}
+
+	relative, err := filepath.Rel(root, resolved)
+	if err != nil {
+		return false, nil
+	}
+
+	return relative != ".." && !strings.HasPrefix(relative, ".."+string(filepath.Separator)), nil
+}
+
+// mkdirInside creates dir and its missing parents below root. A symlinked directory inside root could
+// still point outside of it, so every existing directory is checked before anything is created below it.
+func (e *exporter) mkdirInside(dir string) error {
+	relative, err := filepath.Rel(e.root, dir)
+	if err != nil {
+		return err
+	}
+
+	current := e.root
+	for _, part := range strings.Split(relative, string(filepath.Separator)) {
+		current = filepath.Join(current, part)
+
+		_, err := os.Lstat(current)
+		if errors.Is(err, os.ErrNotExist) {
+			// the parent was checked, so what is created in it stays inside root
+			if err := os.Mkdir(current, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		ok, err := e.inside(current)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return invalidRequestError{"export path should be a file path relative to the export directory"}
+		}
+	}
+
+	return nil
+}
+
+// Export writes data to the relative path through a rename so readers never see half a file
+func (e *exporter) Export(path string, data []byte) error {
+	target, err := e.resolve(path)
+	if err != nil {
+		return err
+	}
+
+	if err := e.mkdirInside(filepath.Dir(target)); err != nil {
+		return err
+	}
+
+	if info, err := os.Lstat(target); err == nil && !info.Mode().IsRegular() {
+		return invalidRequestError{"export path is not a regular file"}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".readme-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), target)
+}
+
+// ExportReadmeFile godoc
+// @Summary Exports the markdown file
+// @Description Renders the readme and writes it to path inside the export directory the server was started with.
+// @Description The path defaults to the readme name with a .md extension, paths leaving the export directory are refused.
+// @Description Without an export directory nothing is written and the markdown file is streamed back like GET does.
+// @Accept json
+// @Produce json,text/markdown
+// @Param	id	path	string	true	"readme id"
+// @Param	export	body	ExportRequest	false	"where to write the file"
+// @Success 200	{object}	HttpMessage	"the path that was written, or the markdown file when exporting is off"
+// @Failure 400	{object}	HttpErrorMessage	"incorrect request body"
+// @Failure 400	{object}	HttpErrorMessage	"export path should be a file path relative to the export directory"
+// @Failure 404	{object}	HttpErrorMessage	"could not find readme"
+// @Failure 422	{object}	HttpErrorMessage	"the elements use undefined variables"
+// @Router 	/readme/{id}/file	[post]
+func (rc *readmeController) exportReadmeFile(c *gin.Context) {
+	readmeId := c.Param("id")
+
+	// callers of the original POST keep getting a 200 when the server doesn't export
+	if rc.exporter == nil {
+		rc.downloadReadmeFile(c)
+		return
+	}
+
+	var request ExportRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.BindJSON(&request); err != nil {
+			c.IndentedJSON(http.StatusBadRequest, HttpErrorMessage{MESSAGE: "incorrect request body, should be ExportRequest body"})
+			return
+		}
+	}
+
+	readme, err := rc.store.Get(readmeId)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	if request.PATH == "" {
+		request.PATH = readmeFileName(readme.NAME)
+	}
+
+	readme, err = rc.render(readme)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	if err := rc.exporter.Export(request.PATH, []byte(renderReadme(readme))); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, HttpMessage{MESSAGE: filepath.ToSlash(filepath.Clean(request.PATH))})
+}
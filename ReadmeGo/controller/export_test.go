@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportReadmeFile(t *testing.T) {
+	root := t.TempDir()
+	router := setupRouter(newMemoryStore(), withExportRoot(root))
+	w := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme?name=billing", nil)
+	router.ServeHTTP(w, req1)
+
+	addParagraph(t, router, "billing", "Handles invoices")
+
+	for body, path := range map[string]string{
+		`{"path": "services/billing/README.md"}`: "services/billing/README.md",
+		``:                                       "billing.md",
+	} {
+		r := httptest.NewRecorder()
+		req2, _ := http.NewRequest("POST", "/readme/billing/file", bytes.NewBufferString(body))
+		router.ServeHTTP(r, req2)
+
+		require.Equal(t, http.StatusOK, r.Code, body)
+		require.JSONEq(t, `{"message": "`+path+`"}`, r.Body.String())
+
+		data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(path)))
+		require.NoError(t, err)
+		require.Equal(t, "Handles invoices\n", string(data))
+	}
+
+	// nothing but the exported files is left behind
+	entries, err := os.ReadDir(filepath.Join(root, "services", "billing"))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+}
+
+func TestExportReadmeFileRefusesToEscapeRoot(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "export")
+	require.NoError(t, os.Mkdir(root, 0755))
+	require.NoError(t, os.Symlink(dir, filepath.Join(root, "link")))
+
+	router := setupRouter(newMemoryStore(), withExportRoot(root))
+	w := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+	router.ServeHTTP(w, req1)
+
+	for _, path := range []string{"../README.md", "docs/../../README.md", "/tmp/README.md", "link/README.md", ".", ".."} {
+		r := httptest.NewRecorder()
+		req2, _ := http.NewRequest("POST", "/readme/1/file", bytes.NewBufferString(`{"path": "`+path+`"}`))
+		router.ServeHTTP(r, req2)
+
+		require.Equal(t, http.StatusBadRequest, r.Code, path)
+	}
+
+	_, err := os.Stat(filepath.Join(dir, "README.md"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestExportReadmeFileCreatesNothingThroughASymlink(t *testing.T) {
+	dir := t.TempDir()
+	outside := filepath.Join(dir, "outside")
+	root := filepath.Join(dir, "export")
+	require.NoError(t, os.Mkdir(outside, 0755))
+	require.NoError(t, os.Mkdir(root, 0755))
+	require.NoError(t, os.Symlink(outside, filepath.Join(root, "link")))
+
+	router := setupRouter(newMemoryStore(), withExportRoot(root))
+	w := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+	router.ServeHTTP(w, req1)
+
+	r := httptest.NewRecorder()
+	req2, _ := http.NewRequest("POST", "/readme/1/file", bytes.NewBufferString(`{"path": "link/docs/api/README.md"}`))
+	router.ServeHTTP(r, req2)
+	require.Equal(t, http.StatusBadRequest, r.Code)
+
+	entries, err := os.ReadDir(outside)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestExportReadmeFileWithoutExportRootStreamsFile(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+	r := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+	router.ServeHTTP(w, req1)
+
+	addParagraph(t, router, "1", "Handles invoices")
+
+	req2, _ := http.NewRequest("POST", "/readme/1/file", nil)
+	router.ServeHTTP(r, req2)
+
+	require.Equal(t, http.StatusOK, r.Code)
+	require.Equal(t, "attachment; filename=1.md", r.Header().Get("Content-Disposition"))
+	require.Equal(t, "Handles invoices\n", r.Body.String())
+}
+
+func TestExportReadmeFileReturnsIncorrectRequestBody(t *testing.T) {
+	router := setupRouter(newMemoryStore(), withExportRoot(t.TempDir()))
+	w := httptest.NewRecorder()
+	r := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+	router.ServeHTTP(w, req1)
+
+	req2, _ := http.NewRequest("POST", "/readme/1/file", bytes.NewBufferString(`{"path": 1}`))
+	router.ServeHTTP(r, req2)
+
+	require.Equal(t, http.StatusBadRequest, r.Code)
+	require.JSONEq(t, `{"message": "incorrect request body, should be ExportRequest body"}`, r.Body.String())
+}
+
+func TestExportReadmeFileReturnsReadmeNotFound(t *testing.T) {
+	router := setupRouter(newMemoryStore(), withExportRoot(t.TempDir()))
+	r := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("POST", "/readme/INVALID/file", nil)
+	router.ServeHTTP(r, req)
+
+	require.Equal(t, http.StatusNotFound, r.Code)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// markdown is rendered to html by goldmark, which leaves out raw html and unsafe link destinations
+var rawMarkdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+//go:embed preview.html
+var previewPage string
+
+var htmlTemplates = template.Must(template.New("readme").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Name}}</title>
+<style>{{.Style}}</style>
+</head>
+<body>
+<article class="markdown-body">
+{{.Body}}</article>
+</body>
+</html>
+`))
+
+func init() {
+	template.Must(htmlTemplates.New("preview").Parse(previewPage))
+}
+
+// readmeStyle is a small subset of the GitHub markdown stylesheet
+const readmeStyle = `
+.markdown-body { box-sizing: border-box; max-width: 980px; margin: 0 auto; padding: 45px; color: #24292f;
+	font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; font-size: 16px; line-height: 1.5; word-wrap: break-word; }
+.markdown-body h1, .markdown-body h2 { padding-bottom: .3em; border-bottom: 1px solid #d0d7de; }
+.markdown-body h1, .markdown-body h2, .markdown-body h3, .markdown-body h4, .markdown-body h5, .markdown-body h6 { margin-top: 24px; margin-bottom: 16px; font-weight: 600; line-height: 1.25; }
+.markdown-body h1 { font-size: 2em; } .markdown-body h2 { font-size: 1.5em; } .markdown-body h3 { font-size: 1.25em; }
+.markdown-body p, .markdown-body blockquote, .markdown-body pre, .markdown-body table { margin-top: 0; margin-bottom: 16px; }
+.markdown-body a { color: #0969da; text-decoration: none; } .markdown-body a:hover { text-decoration: underline; }
+.markdown-body blockquote { margin-left: 0; padding: 0 1em; color: #57606a; border-left: .25em solid #d0d7de; }
+.markdown-body code, .markdown-body pre { font-family: ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; font-size: 85%; }
+.markdown-body pre { padding: 16px; overflow: auto; line-height: 1.45; background-color: #f6f8fa; border-radius: 6px; }
+.markdown-body img { max-width: 100%; }
+.markdown-body table { border-spacing: 0; border-collapse: collapse; }
+.markdown-body th, .markdown-body td { padding: 6px 13px; border: 1px solid #d0d7de; }
+.markdown-body th { font-weight: 600; } .markdown-body tr:nth-child(2n) { background-color: #f6f8fa; }
+`
+
+// renderReadmeHTML returns the html for the whole readme, without the surrounding page. It is the markdown file
+// rendered by goldmark, so the preview shows what the exported markdown holds.
+func renderReadmeHTML(readme Readme) (string, error) {
+	var rendered bytes.Buffer
+	if err := rawMarkdown.Convert([]byte(renderReadme(readme)), &rendered); err != nil {
+		return "", err
+	}
+
+	return rendered.String(), nil
+}
+
+// GetReadmeHTML godoc
+// @Summary Returns a readme as html
+// @Description Renders the readme to a html page styled like GitHub, fragment=true returns only the rendered elements.
+// @Description The markdown file is rendered like GitHub flavored markdown, raw html is left out and unsafe links point nowhere.
+// @Accept json
+// @Produce html
+// @Param	id	path	string	true	"readme id"
+// @Param	fragment	query	bool	false	"only return the rendered elements"
+// @Success 200	{string}	string	"the html page"
+// @Failure 404	{object}	HttpErrorMessage	"could not find readme"
+// @Failure 422	{object}	HttpErrorMessage	"the elements use undefined variables"
+// @Router 	/readme/{id}/html	[get]
+func (rc *readmeController) getReadmeHTML(c *gin.Context) {
+	readmeId := c.Param("id")
+
+	readme, err := rc.store.Get(readmeId)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	readme, err = rc.render(readme)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	body, err := renderReadmeHTML(readme)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	if c.Query("fragment") == "true" {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(body))
+		return
+	}
+
+	var page bytes.Buffer
+	err = htmlTemplates.ExecuteTemplate(&page, "readme", map[string]interface{}{
+		"Name":  readme.NAME,
+		"Style": template.CSS(readmeStyle),
+		// goldmark leaves raw html out of the body
+		"Body": template.HTML(body),
+	})
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.Data(http.StatusOK, "text/html; charset=utf-8", page.Bytes())
+}
+
+// previewReadme serves a page that shows the rendered readme and refreshes it on every change event
+func (rc *readmeController) previewReadme(c *gin.Context) {
+	readmeId := c.Param("id")
+
+	if _, err := rc.store.Get(readmeId); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	var page bytes.Buffer
+	err := htmlTemplates.ExecuteTemplate(&page, "preview", map[string]interface{}{
+		"Name":  readmeId,
+		"Style": template.CSS(readmeStyle),
+	})
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.Data(http.StatusOK, "text/html; charset=utf-8", page.Bytes())
+}
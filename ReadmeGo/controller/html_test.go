@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderReadmeHTML(t *testing.T) {
+	for _, test := range []struct {
+		element  Element
+		expected string
+	}{
+		{Element{TYPE: HeaderElement, LEVEL: 2, VALUE: "Tom & Jerry"}, "<h2>Tom &amp; Jerry</h2>\n"},
+		{Element{TYPE: HeaderElement, VALUE: "legacy header"}, "<p>legacy header</p>\n"},
+		{Element{TYPE: HeaderElement, LEVEL: 7, VALUE: "too deep"}, "<p>####### too deep</p>\n"},
+		{Element{TYPE: ParagraphElement, VALUE: "run `go test` and read the [docs](https://example.com) *first*"},
+			`<p>run <code>go test</code> and read the <a href="https://example.com">docs</a> <em>first</em></p>` + "\n"},
+		{Element{TYPE: ParagraphElement, VALUE: "<script>alert(1)</script>"}, "<!-- raw HTML omitted -->\n"},
+		{Element{TYPE: CodeElement, CODE_LANGUAGE: "go", VALUE: "a < b"}, `<pre><code class="language-go">a &lt; b` + "\n</code></pre>\n"},
+		{Element{TYPE: BlockquoteElement, VALUE: "quote with `code`"}, "<blockquote>\n<p>quote with <code>code</code></p>\n</blockquote>\n"},
+		{Element{TYPE: LinkElement, DESCRIPTION: "docs", LINK: "https://example.com/?a=1&b=2"}, `<p><a href="https://example.com/?a=1&amp;b=2">docs</a></p>` + "\n"},
+		{Element{TYPE: LinkElement, DESCRIPTION: "click", LINK: "javascript:alert(1)"}, `<p><a href="">click</a></p>` + "\n"},
+		{Element{TYPE: ImageElement, DESCRIPTION: "logo", LINK: "logo.png"}, `<p><img src="logo.png" alt="logo"></p>` + "\n"},
+		{Element{TYPE: TableElement, COLUMN_NAMES: []string{"a", "b"}, COLUMN_VALUES: map[string][]string{"a": {"**1**", "2"}, "b": {"3"}}},
+			"<table>\n<thead>\n<tr>\n<th>a</th>\n<th>b</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td><strong>1</strong></td>\n<td>3</td>\n</tr>\n<tr>\n<td>2</td>\n<td></td>\n</tr>\n</tbody>\n</table>\n"},
+		{Element{TYPE: RawElement, VALUE: "# raw *markdown*\n"}, "<h1>raw <em>markdown</em></h1>\n"},
+		{Element{TYPE: RawElement, VALUE: "<script>alert(1)</script>\n\n[x](javascript:alert(1))\n"}, "<!-- raw HTML omitted -->\n<p><a href=\"\">x</a></p>\n"},
+	} {
+		rendered, err := renderReadmeHTML(Readme{ELEMENTS: []Element{test.element}})
+		require.NoError(t, err)
+		require.Equal(t, test.expected, rendered)
+	}
+}
+
+// the html shows the readme the way the markdown reads, blocks that run together in the markdown do in the html too
+func TestRenderReadmeHTMLFollowsTheMarkdown(t *testing.T) {
+	rendered, err := renderReadmeHTML(Readme{ELEMENTS: []Element{
+		{TYPE: ParagraphElement, VALUE: "first"},
+		{TYPE: ParagraphElement, VALUE: "second"},
+	}})
+	require.NoError(t, err)
+	require.Equal(t, "<p>first\nsecond</p>\n", rendered)
+}
+
+func TestGetReadmeHTML(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+	r := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+	router.ServeHTTP(w, req1)
+
+	req2, _ := http.NewRequest("PUT", "/readme/1/header", bytes.NewBufferString(`{"header_type": "LARGE_HEADING", "value": "Title"}`))
+	router.ServeHTTP(w, req2)
+
+	req3, _ := http.NewRequest("GET", "/readme/1/html", nil)
+	router.ServeHTTP(r, req3)
+
+	require.Equal(t, http.StatusOK, r.Code)
+	require.Equal(t, "text/html; charset=utf-8", r.Header().Get("Content-Type"))
+	require.Contains(t, r.Body.String(), "<title>1</title>")
+	require.Contains(t, r.Body.String(), "<article class=\"markdown-body\">\n<h1>Title</h1>\n</article>")
+
+	r = httptest.NewRecorder()
+	req4, _ := http.NewRequest("GET", "/readme/1/html?fragment=true", nil)
+	router.ServeHTTP(r, req4)
+
+	require.Equal(t, "<h1>Title</h1>\n", r.Body.String())
+}
+
+func TestPreviewReadme(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+	r := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme?name=%3Cb%3E", nil)
+	router.ServeHTTP(w, req1)
+
+	req2, _ := http.NewRequest("GET", "/preview/%3Cb%3E", nil)
+	router.ServeHTTP(r, req2)
+
+	require.Equal(t, http.StatusOK, r.Code)
+	require.Contains(t, r.Body.String(), `"\u003cb\u003e"`)
+	require.Contains(t, r.Body.String(), `"/events"`)
+
+	r = httptest.NewRecorder()
+	req3, _ := http.NewRequest("GET", "/preview/INVALID", nil)
+	router.ServeHTTP(r, req3)
+
+	require.Equal(t, http.StatusNotFound, r.Code)
+}
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// maxImportSize is the largest markdown file that can be imported, in bytes
+const maxImportSize = 1 << 20
+
+var (
+	atxHeadingPattern     = regexp.MustCompile(`^(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	setextUnderline       = regexp.MustCompile(`^(=+|-+)[ \t]*$`)
+	fencePattern          = regexp.MustCompile("^(```+|~~~+)[ \t]*([^ \t`]*)[ \t]*$")
+	tableSeparatorPattern = regexp.MustCompile(`^\|?(?:[ \t]*:?-+:?[ \t]*\|)*[ \t]*:?-+:?[ \t]*\|?[ \t]*$`)
+	linkPattern           = regexp.MustCompile(`^(!?)\[([^\[\]]+)\]\(([^()\s]+)\)$`)
+	// lists, html and thematic breaks can't be built from elements and are kept as they are
+	rawBlockPattern = regexp.MustCompile(`^(?:[ \t]*(?:[-*+]|\d{1,9}[.)])(?:[ \t]|$)|[ \t]*<|(?:[ \t]*[-*_]){3,}[ \t]*$)`)
+	// indented code is raw too, but only where it can't be the continuation of a paragraph
+	indentedCodePattern = regexp.MustCompile(`^(?:    |\t)`)
+)
+
+// markdownBlock is one block of an imported markdown document with the element it becomes
+type markdownBlock struct {
+	// source is the exact text of the block including its line endings
+	source  string
+	element Element
+}
+
+// line returns the line without its line ending
+func line(lines []string, i int) string {
+	return strings.TrimRight(lines[i], "\r\n")
+}
+
+func blank(text string) bool {
+	return strings.TrimSpace(text) == ""
+}
+
+// startsBlock reports whether the line can't be part of a paragraph running into it
+func startsBlock(text string) bool {
+	return atxHeadingPattern.MatchString(text) || fencePattern.MatchString(text) ||
+		strings.HasPrefix(text, ">") || rawBlockPattern.MatchString(text)
+}
+
+// parseMarkdown splits markdown into blocks, each turned into the element that renders closest to it.
+// Anything the elements can't express becomes a raw element.
+func parseMarkdown(markdown string) []markdownBlock {
+	lines := strings.SplitAfter(markdown, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	var blocks []markdownBlock
+	for i := 0; i < len(lines); {
+		end, element := parseBlock(lines, i)
+		source := strings.Join(lines[i:end], "")
+		if validateElement(element) != nil {
+			element = Element{TYPE: RawElement, VALUE: source}
+		}
+
+		blocks = append(blocks, markdownBlock{source: source, element: element})
+		i = end
+	}
+
+	return blocks
+}
+
+// parseBlock parses the block starting at line start and returns where it ends
+func parseBlock(lines []string, start int) (int, Element) {
+	text := line(lines, start)
+
+	if blank(text) {
+		end := start
+		for end < len(lines) && blank(line(lines, end)) {
+			end++
+		}
+		return end, Element{TYPE: RawElement, VALUE: strings.Join(lines[start:end], "")}
+	}
+
+	if match := fencePattern.FindStringSubmatch(text); match != nil {
+		return parseFence(lines, start, match[1], match[2])
+	}
+
+	if match := atxHeadingPattern.FindStringSubmatch(text); match != nil {
+		return start + 1, Element{TYPE: HeaderElement, LEVEL: len(match[1]), VALUE: match[2]}
+	}
+
+	if strings.HasPrefix(text, ">") {
+		end := start
+		var quoted []string
+		for end < len(lines) && strings.HasPrefix(line(lines, end), ">") {
+			quoted = append(quoted, strings.TrimPrefix(strings.TrimPrefix(line(lines, end), ">"), " "))
+			end++
+		}
+		return end, Element{TYPE: BlockquoteElement, VALUE: strings.Join(quoted, "\n")}
+	}
+
+	if strings.HasPrefix(text, "|") && start+1 < len(lines) && tableSeparatorPattern.MatchString(line(lines, start+1)) {
+		return parseTable(lines, start)
+	}
+
+	if rawBlockPattern.MatchString(text) || indentedCodePattern.MatchString(text) {
+		end := start + 1
+		for end < len(lines) && !blank(line(lines, end)) {
+			end++
+		}
+		return end, Element{TYPE: RawElement, VALUE: strings.Join(lines[start:end], "")}
+	}
+
+	if match := linkPattern.FindStringSubmatch(text); match != nil {
+		element := Element{TYPE: LinkElement, DESCRIPTION: match[2], LINK: match[3]}
+		if match[1] == "!" {
+			element.TYPE = ImageElement
+		}
+		return start + 1, element
+	}
+
+	// a paragraph runs until a blank line or something that starts another block
+	end := start + 1
+	for end < len(lines) && !blank(line(lines, end)) {
+		if end == start+1 && setextUnderline.MatchString(line(lines, end)) {
+			level := 1
+			if strings.HasPrefix(line(lines, end), "-") {
+				level = 2
+			}
+			return end + 1, Element{TYPE: HeaderElement, LEVEL: level, VALUE: strings.TrimSpace(text)}
+		}
+		if startsBlock(line(lines, end)) {
+			break
+		}
+		end++
+	}
+
+	paragraph := make([]string, 0, end-start)
+	for i := start; i < end; i++ {
+		paragraph = append(paragraph, line(lines, i))
+	}
+	return end, Element{TYPE: ParagraphElement, VALUE: strings.Join(paragraph, "\n")}
+}
+
+// parseFence parses a fenced code block, code in a language the builder doesn't support stays raw
+func parseFence(lines []string, start int, fence string, language string) (int, Element) {
+	end := start + 1
+	closed := false
+	for end < len(lines) {
+		text := strings.TrimSpace(line(lines, end))
+		end++
+		if strings.HasPrefix(text, fence) && strings.Trim(text, fence[:1]) == "" {
+			closed = true
+			break
+		}
+	}
+
+	if !closed || !codeLanguageMap[language] {
+		return end, Element{TYPE: RawElement, VALUE: strings.Join(lines[start:end], "")}
+	}
+
+	code := make([]string, 0, end-start-2)
+	for i := start + 1; i < end-1; i++ {
+		code = append(code, line(lines, i))
+	}
+
+	return end, Element{TYPE: CodeElement, CODE_LANGUAGE: language, VALUE: strings.Join(code, "\n")}
+}
+
+// tableCells splits a table row into its trimmed cells
+func tableCells(row string) []string {
+	row = strings.TrimSpace(row)
+	row = strings.TrimPrefix(row, "|")
+	if strings.HasSuffix(row, "|") && !strings.HasSuffix(row, `\|`) {
+		row = strings.TrimSuffix(row, "|")
+	}
+
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(row); i++ {
+		switch {
+		case row[i] == '\\' && i+1 < len(row) && row[i+1] == '|':
+			cell.WriteString(`\|`)
+			i++
+		case row[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(row[i])
+		}
+	}
+
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// parseTable parses a table, tables whose columns can't be told apart by name stay raw
+func parseTable(lines []string, start int) (int, Element) {
+	end := start + 2
+	for end < len(lines) && strings.HasPrefix(line(lines, end), "|") {
+		end++
+	}
+
+	raw := Element{TYPE: RawElement, VALUE: strings.Join(lines[start:end], "")}
+	names := tableCells(line(lines, start))
+	values := make(map[string][]string, len(names))
+	for _, name := range names {
+		if name == "" || values[name] != nil {
+			return end, raw
+		}
+		values[name] = []string{}
+	}
+
+	for i := start + 2; i < end; i++ {
+		cells := tableCells(line(lines, i))
+		if len(cells) > len(names) {
+			return end, raw
+		}
+		for column, name := range names {
+			cell := ""
+			if column < len(cells) {
+				cell = cells[column]
+			}
+			values[name] = append(values[name], cell)
+		}
+	}
+
+	return end, Element{TYPE: TableElement, COLUMN_NAMES: names, COLUMN_VALUES: values}
+}
+
+// markdownBlocks returns the top level blocks goldmark parses the markdown into. ChildCount can't be used for
+// this, it goes stale when the table extension turns a paragraph into a table.
+func markdownBlocks(markdown string) []ast.Node {
+	var blocks []ast.Node
+	document := rawMarkdown.Parser().Parse(text.NewReader([]byte(markdown)))
+	for node := document.FirstChild(); node != nil; node = node.NextSibling() {
+		blocks = append(blocks, node)
+	}
+
+	return blocks
+}
+
+// parsedBlock parses markdown that should be a single block, ok is false when goldmark sees any other number of blocks
+func parsedBlock(markdown string) (node ast.Node, ok bool) {
+	blocks := markdownBlocks(markdown)
+	if len(blocks) != 1 {
+		return nil, false
+	}
+
+	return blocks[0], true
+}
+
+// sameBlock reports whether the element renders to the same kind of goldmark block as the source. With
+// sameContent the block also has to render to the same html, so only the formatting of the markdown differs.
+func sameBlock(source string, element Element, sameContent bool) bool {
+	rendered := renderElement(element)
+
+	sourceBlock, ok := parsedBlock(source)
+	if !ok {
+		return false
+	}
+
+	renderedBlock, ok := parsedBlock(rendered)
+	if !ok || sourceBlock.Kind() != renderedBlock.Kind() {
+		return false
+	}
+
+	if !sameContent {
+		return true
+	}
+
+	var sourceHTML, renderedHTML bytes.Buffer
+	if rawMarkdown.Convert([]byte(source), &sourceHTML) != nil || rawMarkdown.Convert([]byte(rendered), &renderedHTML) != nil {
+		return false
+	}
+
+	return sourceHTML.String() == renderedHTML.String()
+}
+
+// importElements turns markdown into the elements of a new readme. A block becomes a typed element when goldmark
+// parses the element's markdown to the same kind of block with the same html, so rendering the readme gives back
+// the same document even where its formatting changes, like the spacing of a table. Blocks that wouldn't come back
+// the same and the blank lines between blocks are kept as raw elements.
+//
+// With normalize every block the elements can express becomes a typed element as long as it stays the same kind of
+// block, even if its content renders differently, and the blank lines between blocks are kept as a single blank line
+// so blocks don't run together.
+func importElements(markdown string, normalize bool) []Element {
+	elements := []Element{}
+	for _, block := range parseMarkdown(markdown) {
+		element := block.element
+		if normalize && element.TYPE == RawElement && blank(element.VALUE) {
+			element.VALUE = "\n"
+		}
+
+		if element.TYPE != RawElement && !sameBlock(block.source, element, !normalize) {
+			element = Element{TYPE: RawElement, VALUE: block.source}
+		}
+
+		element.ID = uuid.NewString()
+		elements = append(elements, element)
+	}
+
+	return elements
+}
+
+// ImportReadme godoc
+// @Summary Creates a readme from a markdown file
+// @Description Parses the markdown into headers, paragraphs, code, blockquotes, links, images and tables that can be edited
+// @Description one at a time. Anything else, like lists or html, is kept as raw markdown. The markdown is the request body,
+// @Description or the file field of a multipart form. Rendering the readme gives back a document with the same html, blocks
+// @Description that wouldn't render the same and blank lines are kept raw. normalize=true makes every block the elements
+// @Description can express typed instead, at the cost of changing it, and turns blank lines between blocks into one.
+// @Accept text/markdown
+// @Accept multipart/form-data
+// @Produce json
+// @Param	name	query	string	false	"pass a value to create a user defined readmeId"
+// @Param	markdown	body	string	false	"the markdown document"
+// @Param	file	formData	file	false	"the markdown file"
+// @Param	normalize	query	bool	false	"prefer typed elements over keeping the markdown as it is"
+// @Param	X-Author	header	string	false	"who made the change"
+// @Success	201		{object}	HttpMessage	"returns a message with the readmeId"
+// @Failure	400		{object}	HttpErrorMessage	"could not read the markdown"
+// @Failure	409		{object}	HttpErrorMessage	"Readme already exists"
+// @Router	/readme/import	[post]
+func (rc *readmeController) importReadme(c *gin.Context) {
+	readmeId := c.Query("name")
+	if readmeId == "" {
+		readmeId = uuid.NewString()
+	}
+
+	var body io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		file, err := c.FormFile("file")
+		if err != nil {
+			c.IndentedJSON(http.StatusBadRequest, HttpErrorMessage{MESSAGE: "the markdown should be sent as the file field"})
+			return
+		}
+
+		opened, err := file.Open()
+		if err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		defer opened.Close()
+		body = opened
+	}
+
+	markdown, err := io.ReadAll(io.LimitReader(body, maxImportSize+1))
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, HttpErrorMessage{MESSAGE: "could not read the markdown"})
+		return
+	}
+
+	if len(markdown) > maxImportSize {
+		c.IndentedJSON(http.StatusRequestEntityTooLarge, HttpErrorMessage{MESSAGE: "markdown should be at most 1MB"})
+		return
+	}
+
+	if err := rc.create(c, Readme{NAME: readmeId, ELEMENTS: importElements(string(markdown), c.Query("normalize") == "true")}, "import"); err != nil {
+		respondCreateError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusCreated, HttpMessage{MESSAGE: readmeId})
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const importMarkdown = "# Billing service\n" +
+	"\n" +
+	"Handles invoices\n" +
+	"and refunds.\n" +
+	"\n" +
+	"Setup\n" +
+	"-----\n" +
+	"\n" +
+	"```go\n" +
+	"fmt.Println(\"hi\")\n" +
+	"```\n" +
+	"\n" +
+	"```python\n" +
+	"print('hi')\n" +
+	"```\n" +
+	"> Read the docs\n" +
+	"\n" +
+	"[Docs](https://example.com/docs)\n" +
+	"![Logo](logo.png)\n" +
+	"\n" +
+	"| Name | Port |\n" +
+	"| --- | --- |\n" +
+	"| api | 8080 |\n" +
+	"| db |\n" +
+	"\n" +
+	"- one\n" +
+	"- two\n"
+
+func TestImportElementsNormalized(t *testing.T) {
+	elements := importElements(importMarkdown, true)
+	for i := range elements {
+		require.NotEmpty(t, elements[i].ID)
+		elements[i].ID = ""
+	}
+
+	require.Equal(t, []Element{
+		{TYPE: HeaderElement, LEVEL: 1, VALUE: "Billing service"},
+		{TYPE: RawElement, VALUE: "\n"},
+		{TYPE: ParagraphElement, VALUE: "Handles invoices\nand refunds."},
+		{TYPE: RawElement, VALUE: "\n"},
+		{TYPE: HeaderElement, LEVEL: 2, VALUE: "Setup"},
+		{TYPE: RawElement, VALUE: "\n"},
+		{TYPE: CodeElement, CODE_LANGUAGE: "go", VALUE: `fmt.Println("hi")`},
+		{TYPE: RawElement, VALUE: "\n"},
+		{TYPE: RawElement, VALUE: "```python\nprint('hi')\n```\n"},
+		{TYPE: BlockquoteElement, VALUE: "Read the docs"},
+		{TYPE: RawElement, VALUE: "\n"},
+		{TYPE: LinkElement, DESCRIPTION: "Docs", LINK: "https://example.com/docs"},
+		{TYPE: ImageElement, DESCRIPTION: "Logo", LINK: "logo.png"},
+		{TYPE: RawElement, VALUE: "\n"},
+		{TYPE: TableElement, COLUMN_NAMES: []string{"Name", "Port"}, COLUMN_VALUES: map[string][]string{"Name": {"api", "db"}, "Port": {"8080", ""}}},
+		{TYPE: RawElement, VALUE: "\n"},
+		{TYPE: RawElement, VALUE: "- one\n- two\n"},
+	}, elements)
+}
+
+func TestImportElementsKeepsWhatElementsCannotHold(t *testing.T) {
+	for _, markdown := range []string{
+		"#\n",
+		"| a | a |\n| --- | --- |\n",
+		"```go\nunclosed\n",
+		"<div>html</div>\n",
+		"***\n",
+		"    indented code\n",
+	} {
+		elements := importElements(markdown, true)
+		require.Len(t, elements, 1, markdown)
+		require.Equal(t, RawElement, elements[0].TYPE, markdown)
+		require.Equal(t, markdown, elements[0].VALUE)
+	}
+}
+
+func TestImportReadme(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	r := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme/import?name=billing&normalize=true", bytes.NewBufferString(importMarkdown))
+	req1.Header.Set("Content-Type", "text/markdown")
+	router.ServeHTTP(r, req1)
+
+	require.Equal(t, http.StatusCreated, r.Code)
+	require.JSONEq(t, `{"message": "billing"}`, r.Body.String())
+
+	r = httptest.NewRecorder()
+	req2, _ := http.NewRequest("GET", "/readme/billing?format=elements", nil)
+	router.ServeHTTP(r, req2)
+
+	var readme Readme
+	require.NoError(t, json.Unmarshal(r.Body.Bytes(), &readme))
+	require.Len(t, readme.ELEMENTS, 17)
+
+	r = httptest.NewRecorder()
+	req3, _ := http.NewRequest("GET", "/readme/billing/revisions", nil)
+	router.ServeHTTP(r, req3)
+
+	var revisions []Revision
+	require.NoError(t, json.Unmarshal(r.Body.Bytes(), &revisions))
+	require.Equal(t, "import", revisions[0].OPERATION)
+
+	r = httptest.NewRecorder()
+	req4, _ := http.NewRequest("POST", "/readme/import?name=billing", bytes.NewBufferString(importMarkdown))
+	router.ServeHTTP(r, req4)
+
+	require.Equal(t, http.StatusConflict, r.Code)
+}
+
+func TestImportReadmeFromMultipartForm(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	r := httptest.NewRecorder()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	file, err := form.CreateFormFile("file", "README.md")
+	require.NoError(t, err)
+	_, err = file.Write([]byte("# Title\n"))
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	req1, _ := http.NewRequest("POST", "/readme/import?name=1", &body)
+	req1.Header.Set("Content-Type", form.FormDataContentType())
+	router.ServeHTTP(r, req1)
+
+	require.Equal(t, http.StatusCreated, r.Code)
+	require.Equal(t, []string{"# Title\n"}, getRenderedReadme(t, router, "1"))
+}
+
+func TestImportNormalizedKeepsTheBlocks(t *testing.T) {
+	documents := roundTripCorpus(t)
+	documents["import"] = importMarkdown
+	documents["separated"] = "```go\nfmt.Println(1)\n```\n\n## Next\n\nFirst paragraph.\n\nSecond paragraph.\n\n| a | b |\n| --- | --- |\n| 1 | 2 |\n\nAfter the table.\n"
+
+	for name, markdown := range documents {
+		rendered := renderReadme(Readme{ELEMENTS: importElements(markdown, true)})
+		require.Len(t, markdownBlocks(rendered), len(markdownBlocks(markdown)), name+"\n"+rendered)
+	}
+}
+
+func TestImportElementsTypesCommonlyFormattedBlocks(t *testing.T) {
+	elements := importElements("```go\nfmt.Println(1)\n```\n\n| Name | Port |\n| ---- | ---- |\n| api  | 8080 |\n", false)
+	for i := range elements {
+		elements[i].ID = ""
+	}
+
+	require.Equal(t, []Element{
+		{TYPE: CodeElement, CODE_LANGUAGE: "go", VALUE: "fmt.Println(1)"},
+		{TYPE: RawElement, VALUE: "\n"},
+		{TYPE: TableElement, COLUMN_NAMES: []string{"Name", "Port"}, COLUMN_VALUES: map[string][]string{"Name": {"api"}, "Port": {"8080"}}},
+	}, elements)
+
+	// an aligned column would lose its alignment as a table element
+	aligned := importElements("| Name | Port |\n| :--- | ---: |\n| api | 8080 |\n", false)
+	require.Equal(t, RawElement, aligned[0].TYPE)
+}
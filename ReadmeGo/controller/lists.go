@@ -0,0 +1,347 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var ErrItemNotFound = errors.New("could not find item")
+
+// maxListStart is the largest number an ordered list can start at, GFM only allows nine digits
+const maxListStart = 999999999
+
+// List is an ordered or unordered list, any item can hold a nested list of its own style
+type List struct {
+	ORDERED bool `json:"ordered"`
+	// START is the number of the first item of an ordered list, 1 when left out
+	START int        `json:"start,omitempty"`
+	ITEMS []ListItem `json:"items" binding:"required"`
+}
+
+type ListItem struct {
+	// ID and CHECKED are only used by task lists, where every item is a task that can be ticked off
+	ID      string `json:"id,omitempty"`
+	CHECKED bool   `json:"checked,omitempty"`
+	VALUE   string `json:"value" binding:"required"`
+	// LIST is nested under the item, nil when the item has no children
+	LIST *List `json:"list,omitempty"`
+}
+
+type AddListRequest struct {
+	ORDERED bool       `json:"ordered"`
+	START   int        `json:"start,omitempty"`
+	ITEMS   []ListItem `json:"items" binding:"required"`
+}
+
+type TaskItemResponse struct {
+	ELEMENT_ID string `json:"element_id" binding:"required"`
+	ITEM_ID    string `json:"item_id" binding:"required"`
+	CHECKED    bool   `json:"checked"`
+	MESSAGE    string `json:"message" binding:"required"`
+}
+
+// element makes a list or a task list, they take the same request
+func (r AddListRequest) element(elementType ElementType) Element {
+	return Element{TYPE: elementType, LIST: &List{ORDERED: r.ORDERED, START: r.START, ITEMS: r.ITEMS}}
+}
+
+func (l List) clone() List {
+	items := make([]ListItem, len(l.ITEMS))
+	for i, item := range l.ITEMS {
+		if item.LIST != nil {
+			nested := item.LIST.clone()
+			item.LIST = &nested
+		}
+		items[i] = item
+	}
+	l.ITEMS = items
+
+	return l
+}
+
+// first is the number of the first item, ordered lists start at 1 unless START is set
+func (l List) first() int {
+	if l.START == 0 {
+		return 1
+	}
+
+	return l.START
+}
+
+// marker is what starts the item at index, followed by the space separating it from the value
+func (l List) marker(index int) string {
+	if l.ORDERED {
+		return strconv.Itoa(l.first()+index) + ". "
+	}
+
+	return "- "
+}
+
+// item finds the item with the id in the list or any nested list
+func (l *List) item(itemId string) (*ListItem, error) {
+	for i := range l.ITEMS {
+		if l.ITEMS[i].ID == itemId {
+			return &l.ITEMS[i], nil
+		}
+
+		if l.ITEMS[i].LIST != nil {
+			if item, err := l.ITEMS[i].LIST.item(itemId); err == nil {
+				return item, nil
+			}
+		}
+	}
+
+	return nil, ErrItemNotFound
+}
+
+// fillItemIds gives every task without an id a new one, ids already set are kept
+func (l *List) fillItemIds() {
+	for i := range l.ITEMS {
+		if l.ITEMS[i].ID == "" {
+			l.ITEMS[i].ID = uuid.NewString()
+		}
+
+		if l.ITEMS[i].LIST != nil {
+			l.ITEMS[i].LIST.fillItemIds()
+		}
+	}
+}
+
+// fillTaskIds gives the tasks of every task list an id so they can be ticked off by id
+func fillTaskIds(elements []Element) {
+	for _, element := range elements {
+		if element.TYPE == TaskListElement && element.LIST != nil {
+			element.LIST.fillItemIds()
+		}
+	}
+}
+
+// validateList checks the list and every nested list, task ids have to be unique across the whole task list
+func validateList(list *List, task bool) error {
+	return validateItems(list, task, map[string]bool{})
+}
+
+func validateItems(list *List, task bool, ids map[string]bool) error {
+	if list == nil || len(list.ITEMS) == 0 {
+		return invalidRequestError{"list needs at least one item"}
+	}
+
+	if list.START != 0 && !list.ORDERED {
+		return invalidRequestError{"only ordered lists have a start number"}
+	}
+
+	if list.START < 0 || list.START > maxListStart {
+		return invalidRequestError{"list start should be between 0 and " + strconv.Itoa(maxListStart)}
+	}
+
+	for _, item := range list.ITEMS {
+		if strings.TrimSpace(item.VALUE) == "" {
+			return invalidRequestError{"list items cannot be empty"}
+		}
+
+		if !task && (item.CHECKED || item.ID != "") {
+			return invalidRequestError{"only task list items can be checked"}
+		}
+
+		if item.ID != "" {
+			if ids[item.ID] {
+				return invalidRequestError{"task list item ids must be unique"}
+			}
+			ids[item.ID] = true
+		}
+
+		if item.LIST != nil {
+			if err := validateItems(item.LIST, task, ids); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// listTextFields returns the values of every item, nested items included
+func listTextFields(list *List) []*string {
+	if list == nil {
+		return nil
+	}
+
+	var fields []*string
+	for i := range list.ITEMS {
+		fields = append(fields, &list.ITEMS[i].VALUE)
+		fields = append(fields, listTextFields(list.ITEMS[i].LIST)...)
+	}
+
+	return fields
+}
+
+// taskMarker is the checkbox in front of a task
+func taskMarker(item ListItem) string {
+	if item.CHECKED {
+		return "[x] "
+	}
+
+	return "[ ] "
+}
+
+// renderList writes the list as GFM, lines after the first of an item and nested lists are indented
+// to line up with the text after the marker so they stay inside the item. Nested lists of a task list are tasks too.
+func renderList(list List, indent string, task bool, markdown *strings.Builder) {
+	for i, item := range list.ITEMS {
+		marker := list.marker(i)
+		content := indent + strings.Repeat(" ", len(marker))
+
+		lines := strings.Split(strings.TrimRight(item.VALUE, "\n"), "\n")
+		if task {
+			lines[0] = taskMarker(item) + lines[0]
+		}
+		markdown.WriteString(indent + marker + lines[0] + "\n")
+		for _, line := range lines[1:] {
+			if strings.TrimSpace(line) != "" {
+				markdown.WriteString(content + line)
+			}
+			markdown.WriteString("\n")
+		}
+
+		if item.LIST != nil {
+			renderList(*item.LIST, content, task, markdown)
+		}
+	}
+}
+
+// AddList godoc
+// @Summary Add List
+// @Description creates an ordered or unordered markdown list, items can hold nested lists to any depth
+// @Accept json
+// @Produce json
+// @Param	id	path	string	true	"readme id"
+// @Param	addListRequest	body	AddListRequest	true	"request list body"
+// @Param	index	query	int	false	"insert the element at this index"
+// @Param	before	query	string	false	"insert the element before this element id"
+// @Param	after	query	string	false	"insert the element after this element id"
+// @Param	X-Author	header	string	false	"who made the change"
+// @Success	200	{object}	ElementResponse	"returns the list markdown string"
+// @Failure 404	{object}	HttpErrorMessage	"could not find readme"
+// @Failure 400	{object}	HttpErrorMessage	"incorrect request body"
+// @Failure 400	{object}	HttpErrorMessage	"list items cannot be empty"
+// @Router	/readme/{id}/list	[put]
+func (rc *readmeController) addList(c *gin.Context) {
+	readmeId := c.Param("id")
+	var addListRequest AddListRequest
+
+	if err := c.BindJSON(&addListRequest); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, HttpErrorMessage{MESSAGE: "incorrect request body, should be AddListRequest body"})
+		return
+	}
+
+	rc.addElement(c, readmeId, addListRequest.element(ListElement))
+}
+
+// AddTaskList godoc
+// @Summary Add Task List
+// @Description creates a GitHub task list where every item, nested ones included, is a task with a checkbox.
+// @Description Tasks get an id that can be used to tick them off, the response lists them.
+// @Accept json
+// @Produce json
+// @Param	id	path	string	true	"readme id"
+// @Param	addListRequest	body	AddListRequest	true	"request list body, items can be checked"
+// @Param	index	query	int	false	"insert the element at this index"
+// @Param	before	query	string	false	"insert the element before this element id"
+// @Param	after	query	string	false	"insert the element after this element id"
+// @Param	X-Author	header	string	false	"who made the change"
+// @Success	200	{object}	ElementResponse	"returns the task list markdown string and the list with its task ids"
+// @Failure 404	{object}	HttpErrorMessage	"could not find readme"
+// @Failure 400	{object}	HttpErrorMessage	"incorrect request body"
+// @Failure 400	{object}	HttpErrorMessage	"list items cannot be empty"
+// @Router	/readme/{id}/tasklist	[put]
+func (rc *readmeController) addTaskList(c *gin.Context) {
+	readmeId := c.Param("id")
+	var addListRequest AddListRequest
+
+	if err := c.BindJSON(&addListRequest); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, HttpErrorMessage{MESSAGE: "incorrect request body, should be AddListRequest body"})
+		return
+	}
+
+	rc.addElement(c, readmeId, addListRequest.element(TaskListElement))
+}
+
+// toggleTask ticks the task off, or sets it to checked when given, and returns whether it ended up checked
+func toggleTask(readme *Readme, elementId string, itemId string, checked *bool) (bool, error) {
+	index, err := readme.indexOf(elementId)
+	if err != nil {
+		return false, err
+	}
+
+	element := &readme.ELEMENTS[index]
+	if element.TYPE != TaskListElement || element.LIST == nil {
+		return false, invalidRequestError{"element is not a task list"}
+	}
+
+	item, err := element.LIST.item(itemId)
+	if err != nil {
+		return false, err
+	}
+
+	if checked != nil {
+		item.CHECKED = *checked
+	} else {
+		item.CHECKED = !item.CHECKED
+	}
+
+	return item.CHECKED, nil
+}
+
+// ToggleTask godoc
+// @Summary Ticks off a task
+// @Description Flips the checked state of a task list item. With checked set the item is set to it instead,
+// @Description so repeating the call doesn't undo it.
+// @Accept json
+// @Produce json
+// @Param	id	path	string	true	"readme id"
+// @Param	elementId	path	string	true	"task list element id"
+// @Param	itemId	path	string	true	"task id"
+// @Param	checked	query	bool	false	"set the task to this state instead of flipping it"
+// @Param	X-Author	header	string	false	"who made the change"
+// @Success	200	{object}	TaskItemResponse	"the new state of the task and the task list markdown"
+// @Failure 400	{object}	HttpErrorMessage	"the element is not a task list"
+// @Failure 404	{object}	HttpErrorMessage	"could not find readme, element or item"
+// @Router	/readme/{id}/element/{elementId}/item/{itemId}/toggle	[post]
+func (rc *readmeController) toggleTask(c *gin.Context) {
+	readmeId := c.Param("id")
+	elementId := c.Param("elementId")
+	itemId := c.Param("itemId")
+	var checked *bool
+
+	if c.Query("checked") != "" {
+		value, err := strconv.ParseBool(c.Query("checked"))
+		if err != nil {
+			c.IndentedJSON(http.StatusBadRequest, HttpErrorMessage{MESSAGE: "checked should be true or false"})
+			return
+		}
+		checked = &value
+	}
+
+	response := TaskItemResponse{ELEMENT_ID: elementId, ITEM_ID: itemId}
+	_, err := rc.update(c, readmeId, "toggle "+itemId, func(readme *Readme) error {
+		var err error
+		if response.CHECKED, err = toggleTask(readme, elementId, itemId, checked); err != nil {
+			return err
+		}
+
+		index, _ := readme.indexOf(elementId)
+		response.MESSAGE = renderElement(readme.ELEMENTS[index])
+		return nil
+	})
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, response)
+}
@@ -0,0 +1,355 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"github.com/yuin/goldmark/ast"
+)
+
+var listTests = map[string]struct {
+	list     List
+	task     bool
+	markdown string
+}{
+	"unordered": {
+		List{ITEMS: []ListItem{{VALUE: "one"}, {VALUE: "two"}}},
+		false,
+		"- one\n- two\n",
+	},
+	"ordered with start": {
+		List{ORDERED: true, START: 9, ITEMS: []ListItem{{VALUE: "nine"}, {VALUE: "ten"}}},
+		false,
+		"9. nine\n10. ten\n",
+	},
+	"nested to any depth": {
+		List{ITEMS: []ListItem{
+			{VALUE: "install", LIST: &List{ORDERED: true, ITEMS: []ListItem{
+				{VALUE: "download"},
+				{VALUE: "unpack", LIST: &List{ITEMS: []ListItem{{VALUE: "on linux"}, {VALUE: "on mac"}}}},
+			}}},
+			{VALUE: "run"},
+		}},
+		false,
+		"- install\n  1. download\n  2. unpack\n     - on linux\n     - on mac\n- run\n",
+	},
+	"children line up with wide markers": {
+		List{ORDERED: true, START: 10, ITEMS: []ListItem{{VALUE: "ten", LIST: &List{ITEMS: []ListItem{{VALUE: "nested"}}}}}},
+		false,
+		"10. ten\n    - nested\n",
+	},
+	"multi line items": {
+		List{ITEMS: []ListItem{{VALUE: "first line\nsecond line", LIST: &List{ITEMS: []ListItem{{VALUE: "child"}}}}}},
+		false,
+		"- first line\n  second line\n  - child\n",
+	},
+	"task list": {
+		List{ITEMS: []ListItem{
+			{VALUE: "tag the release", CHECKED: true},
+			{VALUE: "publish", LIST: &List{ORDERED: true, ITEMS: []ListItem{{VALUE: "binaries", CHECKED: true}, {VALUE: "docs"}}}},
+		}},
+		true,
+		"- [x] tag the release\n- [ ] publish\n  1. [x] binaries\n  2. [ ] docs\n",
+	},
+}
+
+func listElement(list List, task bool) Element {
+	if task {
+		return Element{TYPE: TaskListElement, LIST: &list}
+	}
+
+	return Element{TYPE: ListElement, LIST: &list}
+}
+
+func TestRenderList(t *testing.T) {
+	for name, test := range listTests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.markdown, renderElement(listElement(test.list, test.task)))
+		})
+	}
+}
+
+// the markdown of a list has to read back as a single list holding the same items
+func TestRenderListMatchesGFM(t *testing.T) {
+	for name, test := range listTests {
+		t.Run(name, func(t *testing.T) {
+			blocks := markdownBlocks(renderElement(listElement(test.list, test.task)))
+			require.Len(t, blocks, 1)
+			require.Equal(t, ast.KindList, blocks[0].Kind())
+			require.Equal(t, len(test.list.ITEMS), blocks[0].ChildCount())
+		})
+	}
+}
+
+func TestAddList(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			router := setupRouter(store)
+			w := httptest.NewRecorder()
+
+			req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+			router.ServeHTTP(w, req1)
+
+			r := httptest.NewRecorder()
+			req2, _ := http.NewRequest("PUT", "/readme/1/list", bytes.NewBufferString(`{"ordered": true, "start": 3, "items": [
+				{"value": "build", "list": {"items": [{"value": "go build ./..."}]}},
+				{"value": "test"}
+			]}`))
+			router.ServeHTTP(r, req2)
+			require.Equal(t, http.StatusOK, r.Code, r.Body.String())
+
+			var response ElementResponse
+			require.NoError(t, json.Unmarshal(r.Body.Bytes(), &response))
+			require.Equal(t, "3. build\n   - go build ./...\n4. test\n", response.MESSAGE)
+
+			require.Equal(t, []string{"3. build\n   - go build ./...\n4. test\n"}, getRenderedReadme(t, router, "1"))
+
+			readme, err := store.Get("1")
+			require.NoError(t, err)
+			require.Equal(t, &List{ORDERED: true, START: 3, ITEMS: []ListItem{
+				{VALUE: "build", LIST: &List{ITEMS: []ListItem{{VALUE: "go build ./..."}}}},
+				{VALUE: "test"},
+			}}, readme.ELEMENTS[0].LIST)
+		})
+	}
+}
+
+func TestAddListReturnsInvalidList(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+	router.ServeHTTP(w, req1)
+
+	for body, message := range map[string]string{
+		`{"items": []}`:                                                   "list needs at least one item",
+		`{"items": [{"value": " "}]}`:                                     "list items cannot be empty",
+		`{"start": 2, "items": [{"value": "a"}]}`:                         "only ordered lists have a start number",
+		`{"ordered": true, "start": -1, "items": [{"value": "a"}]}`:       "list start should be between 0 and 999999999",
+		`{"items": [{"value": "a", "list": {"items": [{"value": ""}]}}]}`: "list items cannot be empty",
+		`{"items": [{"value": "a", "checked": true}]}`:                    "only task list items can be checked",
+	} {
+		r := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/readme/1/list", bytes.NewBufferString(body))
+		router.ServeHTTP(r, req)
+
+		require.Equal(t, http.StatusBadRequest, r.Code, body)
+		require.Equal(t, "{\n    \"message\": \""+message+"\"\n}", r.Body.String(), body)
+	}
+
+	require.Empty(t, getRenderedReadme(t, router, "1"))
+}
+
+func TestListItemsFillInReadmeVariables(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("POST", "/readme/spec", bytes.NewBufferString(`{"name": "1", "variables": {"ModulePath": "example.com/readmego"}, "elements": [
+		{"type": "list", "list": {"items": [{"value": "install", "list": {"ordered": true, "items": [{"value": "go get {{.ModulePath}}"}]}}]}}
+	]}`))
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	require.Equal(t, []string{"- install\n  1. go get example.com/readmego\n"}, getRenderedReadme(t, router, "1"))
+}
+
+func getTaskIds(t *testing.T, router *gin.Engine, readmeId string, elementId string) []string {
+	r := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/readme/"+readmeId+"/element/"+elementId, nil)
+	router.ServeHTTP(r, req)
+	require.Equal(t, http.StatusOK, r.Code)
+
+	var element Element
+	require.NoError(t, json.Unmarshal(r.Body.Bytes(), &element))
+
+	var ids []string
+	var walk func(list *List)
+	walk = func(list *List) {
+		for _, item := range list.ITEMS {
+			require.NotEmpty(t, item.ID)
+			ids = append(ids, item.ID)
+			if item.LIST != nil {
+				walk(item.LIST)
+			}
+		}
+	}
+	walk(element.LIST)
+
+	return ids
+}
+
+func addTaskList(t *testing.T, router *gin.Engine, readmeId string, body string) string {
+	r := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/readme/"+readmeId+"/tasklist", bytes.NewBufferString(body))
+	router.ServeHTTP(r, req)
+	require.Equal(t, http.StatusOK, r.Code, r.Body.String())
+
+	var response ElementResponse
+	require.NoError(t, json.Unmarshal(r.Body.Bytes(), &response))
+	return response.ID
+}
+
+func toggle(t *testing.T, router *gin.Engine, path string) TaskItemResponse {
+	r := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", path, nil)
+	router.ServeHTTP(r, req)
+	require.Equal(t, http.StatusOK, r.Code, r.Body.String())
+
+	var response TaskItemResponse
+	require.NoError(t, json.Unmarshal(r.Body.Bytes(), &response))
+	return response
+}
+
+func TestToggleTask(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			router := setupRouter(store)
+			w := httptest.NewRecorder()
+
+			req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+			router.ServeHTTP(w, req1)
+
+			elementId := addTaskList(t, router, "1", `{"items": [{"value": "tag", "checked": true}, {"value": "publish", "list": {"items": [{"value": "docs"}]}}]}`)
+			require.Equal(t, []string{"- [x] tag\n- [ ] publish\n  - [ ] docs\n"}, getRenderedReadme(t, router, "1"))
+
+			ids := getTaskIds(t, router, "1", elementId)
+			require.Len(t, ids, 3)
+			path := "/readme/1/element/" + elementId + "/item/"
+
+			response := toggle(t, router, path+ids[2]+"/toggle")
+			require.Equal(t, TaskItemResponse{ELEMENT_ID: elementId, ITEM_ID: ids[2], CHECKED: true, MESSAGE: "- [x] tag\n- [ ] publish\n  - [x] docs\n"}, response)
+
+			response = toggle(t, router, path+ids[0]+"/toggle")
+			require.False(t, response.CHECKED)
+
+			// setting the state is safe to repeat
+			for i := 0; i < 2; i++ {
+				response = toggle(t, router, path+ids[1]+"/toggle?checked=true")
+				require.True(t, response.CHECKED)
+			}
+			require.Equal(t, []string{"- [ ] tag\n- [x] publish\n  - [x] docs\n"}, getRenderedReadme(t, router, "1"))
+
+			// the ids survive the changes and every toggle is a revision that can be undone
+			require.Equal(t, ids, getTaskIds(t, router, "1", elementId))
+
+			r := httptest.NewRecorder()
+			req2, _ := http.NewRequest("POST", "/readme/1/undo", nil)
+			router.ServeHTTP(r, req2)
+			require.Equal(t, http.StatusOK, r.Code)
+			require.Equal(t, []string{"- [ ] tag\n- [x] publish\n  - [x] docs\n"}, getRenderedReadme(t, router, "1"))
+
+			r = httptest.NewRecorder()
+			req3, _ := http.NewRequest("POST", "/readme/1/undo", nil)
+			router.ServeHTTP(r, req3)
+			require.Equal(t, []string{"- [ ] tag\n- [ ] publish\n  - [x] docs\n"}, getRenderedReadme(t, router, "1"))
+		})
+	}
+}
+
+func TestAddTaskListReturnsTaskIds(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			router := setupRouter(store)
+			w := httptest.NewRecorder()
+
+			req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+			router.ServeHTTP(w, req1)
+
+			r := httptest.NewRecorder()
+			req2, _ := http.NewRequest("PUT", "/readme/1/tasklist", bytes.NewBufferString(`{"items": [{"id": "tag", "value": "tag"}, {"value": "publish", "list": {"items": [{"value": "docs"}]}}]}`))
+			router.ServeHTTP(r, req2)
+			require.Equal(t, http.StatusOK, r.Code, r.Body.String())
+
+			var response ElementResponse
+			require.NoError(t, json.Unmarshal(r.Body.Bytes(), &response))
+			require.Equal(t, "- [ ] tag\n- [ ] publish\n  - [ ] docs\n", response.MESSAGE)
+
+			// the ids sent back are the stored ones, so a task can be ticked off without reading the element again
+			require.NotNil(t, response.LIST)
+			ids := []string{response.LIST.ITEMS[0].ID, response.LIST.ITEMS[1].ID, response.LIST.ITEMS[1].LIST.ITEMS[0].ID}
+			require.Equal(t, "tag", ids[0])
+			require.Equal(t, ids, getTaskIds(t, router, "1", response.ID))
+
+			toggled := toggle(t, router, "/readme/1/element/"+response.ID+"/item/"+ids[2]+"/toggle")
+			require.True(t, toggled.CHECKED)
+		})
+	}
+}
+
+func TestToggleTaskReturnsErrors(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+	router.ServeHTTP(w, req1)
+
+	elementId := addTaskList(t, router, "1", `{"items": [{"value": "tag"}]}`)
+	itemId := getTaskIds(t, router, "1", elementId)[0]
+
+	r := httptest.NewRecorder()
+	req2, _ := http.NewRequest("PUT", "/readme/1/list", bytes.NewBufferString(`{"items": [{"value": "not a task"}]}`))
+	router.ServeHTTP(r, req2)
+	var list ElementResponse
+	require.NoError(t, json.Unmarshal(r.Body.Bytes(), &list))
+
+	for path, expected := range map[string]struct {
+		status  int
+		message string
+	}{
+		"/readme/1/element/" + elementId + "/item/missing/toggle":                           {http.StatusNotFound, "could not find item"},
+		"/readme/1/element/missing/item/" + itemId + "/toggle":                              {http.StatusNotFound, "could not find element"},
+		"/readme/missing/element/" + elementId + "/item/" + itemId + "/toggle":              {http.StatusNotFound, "could not find readme"},
+		"/readme/1/element/" + list.ID + "/item/" + itemId + "/toggle":                      {http.StatusBadRequest, "element is not a task list"},
+		"/readme/1/element/" + elementId + "/item/" + itemId + "/toggle?checked=yes please": {http.StatusBadRequest, "checked should be true or false"},
+	} {
+		r := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", path, nil)
+		router.ServeHTTP(r, req)
+
+		require.Equal(t, expected.status, r.Code, path)
+		require.Equal(t, "{\n    \"message\": \""+expected.message+"\"\n}", r.Body.String(), path)
+	}
+
+	r = httptest.NewRecorder()
+	req3, _ := http.NewRequest("PUT", "/readme/1/tasklist", bytes.NewBufferString(`{"items": [{"id": "a", "value": "one"}, {"id": "a", "value": "two"}]}`))
+	router.ServeHTTP(r, req3)
+	require.Equal(t, http.StatusBadRequest, r.Code)
+	require.Equal(t, "{\n    \"message\": \"task list item ids must be unique\"\n}", r.Body.String())
+}
+
+func TestBatchTogglesTasksTogether(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+	router.ServeHTTP(w, req1)
+
+	elementId := addTaskList(t, router, "1", `{"items": [{"id": "tag", "value": "tag"}, {"id": "publish", "value": "publish"}]}`)
+
+	r := httptest.NewRecorder()
+	req2, _ := http.NewRequest("POST", "/readme/1/batch", bytes.NewBufferString(`{"operations": [
+		{"operation": "toggle", "element_id": "`+elementId+`", "item_id": "tag"},
+		{"operation": "toggle", "element_id": "`+elementId+`", "item_id": "publish", "checked": true},
+		{"operation": "add_tasklist", "list": {"items": [{"value": "announce"}]}}
+	]}`))
+	router.ServeHTTP(r, req2)
+	require.Equal(t, http.StatusOK, r.Code, r.Body.String())
+
+	require.Equal(t, []string{"- [x] tag\n- [x] publish\n", "- [ ] announce\n"}, getRenderedReadme(t, router, "1"))
+
+	// a missing task rolls the whole batch back
+	r = httptest.NewRecorder()
+	req3, _ := http.NewRequest("POST", "/readme/1/batch", bytes.NewBufferString(`{"operations": [
+		{"operation": "toggle", "element_id": "`+elementId+`", "item_id": "tag"},
+		{"operation": "toggle", "element_id": "`+elementId+`", "item_id": "missing"}
+	]}`))
+	router.ServeHTTP(r, req3)
+	require.Equal(t, http.StatusNotFound, r.Code)
+
+	require.Equal(t, []string{"- [x] tag\n- [x] publish\n", "- [ ] announce\n"}, getRenderedReadme(t, router, "1"))
+}
@@ -1,11 +1,19 @@
 package main
 
 import (
-	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"mime"
 	"net/http"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -13,12 +21,6 @@ import (
 	"github.com/swaggo/gin-swagger/swaggerFiles"
 )
 
-var headingSyntaxMap = map[string]string{
-	"SMALL_HEADING":  "### ",
-	"MEDIUM_HEADING": "## ",
-	"LARGE_HEADING":  "# ",
-}
-
 var codeLanguageMap = map[string]bool{
 	"go":   true,
 	"java": true,
@@ -40,6 +42,26 @@ type MarkDownRequest struct {
 	MDVALUE []string `json:"mdvalue" binding:"required"`
 }
 
+type ElementResponse struct {
+	ID      string `json:"id" binding:"required"`
+	MESSAGE string `json:"message" binding:"required"`
+	// LIST is only set for lists and task lists, with the ids of the tasks so they can be ticked off
+	LIST *List `json:"list,omitempty"`
+}
+
+type MoveElementRequest struct {
+	BEFORE string `json:"before"`
+	AFTER  string `json:"after"`
+	INDEX  *int   `json:"index"`
+}
+
+type ReadmeListResponse struct {
+	READMES   []ReadmeSummary `json:"readmes" binding:"required"`
+	TOTAL     int             `json:"total" binding:"required"`
+	PAGE      int             `json:"page" binding:"required"`
+	PAGE_SIZE int             `json:"page_size" binding:"required"`
+}
+
 type ReadmeResponse struct {
 	NAME   string   `json:"name" binding:"required"`
 	VALUES []string `json:"values" binding:"required"`
@@ -65,28 +87,154 @@ type AddTableRequest struct {
 	COLUMN_VALUES map[string][]string `json:"column_values" binding:"required"`
 }
 
-var readmeDB = make(map[string][]string)
+// validate checks the header type is one of the keys of headingLevelMap
+func (r AddHeaderRequest) validate() error {
+	if _, ok := headingLevelMap[r.HEADER_TYPE]; !ok {
+		return invalidRequestError{"header_type should be one of SMALL_HEADING, MEDIUM_HEADING, LARGE_HEADING"}
+	}
+
+	return nil
+}
+
+func (r AddHeaderRequest) element() Element {
+	return Element{TYPE: HeaderElement, LEVEL: headingLevelMap[r.HEADER_TYPE], VALUE: r.VALUE}
+}
+
+func (r AddCodeRequest) element() Element {
+	return Element{TYPE: CodeElement, CODE_LANGUAGE: r.CODE_LANGUAGE, VALUE: r.VALUE}
+}
+
+// element makes a link or an image, they take the same request
+func (r AddLinkRequest) element(elementType ElementType) Element {
+	return Element{TYPE: elementType, DESCRIPTION: r.DESCRIPTION, LINK: r.LINK}
+}
+
+func (r AddTableRequest) element() Element {
+	return Element{TYPE: TableElement, COLUMN_NAMES: r.COLUMN_NAMES, COLUMN_VALUES: r.COLUMN_VALUES}
+}
+
+// readmeController holds the dependencies shared by the readme handlers
+type readmeController struct {
+	store    ReadmeStore
+	events   *readmeEvents
+	sessions *collabSessions
+	// sequence orders the changes to each readme with the events and session messages they send
+	sequence *keyedMutex
+	// snippets is held for reading while a snippet is checked and included in a readme, and for
+	// writing while a snippet is checked to be unused and deleted
+	snippets sync.RWMutex
+	// exporter is nil unless the server was started with an export directory
+	exporter *exporter
+}
+
+// invalidRequestError is returned from inside a store update when the request doesn't fit the readme
+type invalidRequestError struct {
+	message string
+}
+
+func (e invalidRequestError) Error() string {
+	return e.message
+}
+
+// storeErrorStatus maps an error from the ReadmeStore to a http status and message
+func storeErrorStatus(err error) (int, string) {
+	if errors.Is(err, ErrReadmeNotFound) {
+		return http.StatusNotFound, "could not find readme"
+	}
+
+	if errors.Is(err, ErrElementNotFound) {
+		return http.StatusNotFound, "could not find element"
+	}
+
+	if errors.Is(err, ErrItemNotFound) {
+		return http.StatusNotFound, "could not find item"
+	}
+
+	if errors.Is(err, ErrRevisionNotFound) {
+		return http.StatusNotFound, "could not find revision"
+	}
+
+	if errors.Is(err, ErrNothingToUndo) || errors.Is(err, ErrNothingToRedo) {
+		return http.StatusConflict, err.Error()
+	}
 
-func check(e error) {
-	if e != nil {
-		panic(e)
+	var invalidRequest invalidRequestError
+	if errors.As(err, &invalidRequest) {
+		return http.StatusBadRequest, invalidRequest.message
 	}
+
+	var interpolation interpolationError
+	if errors.As(err, &interpolation) {
+		return http.StatusUnprocessableEntity, interpolation.message
+	}
+
+	return http.StatusInternalServerError, err.Error()
+}
+
+// respondStoreError maps an error from the ReadmeStore to a http response
+func respondStoreError(c *gin.Context, err error) {
+	status, message := storeErrorStatus(err)
+	c.IndentedJSON(status, HttpErrorMessage{MESSAGE: message})
 }
 
 // TODO: definition lists
-func setupRouter() *gin.Engine {
+func setupRouter(store ReadmeStore, options ...func(rc *readmeController)) *gin.Engine {
 	router := gin.New()
+	rc := &readmeController{store: store, events: newReadmeEvents(), sessions: newCollabSessions(), sequence: newKeyedMutex()}
+	for _, option := range options {
+		option(rc)
+	}
 
-	router.POST("/readme", createReadme)
-	router.GET("/readme/:id", getReadme)
-	router.PUT("/readme/:id/header", addHeader)
-	router.PUT("/readme/:id/paragraph", addParagraph)
-	router.PUT("/readme/:id/code", addCode)
-	router.PUT("/readme/:id/blockquote", addBlockquote)
-	router.PUT("/readme/:id/link", addLink)
-	router.PUT("/readme/:id/image", addImage)
-	router.PUT("/readme/:id/table", addTable)
-	router.POST("/readme/:id/file", createReadmeFile)
+	router.POST("/readme", rc.createReadme)
+	router.GET("/readme", rc.listReadmes)
+	router.GET("/readme/archive", rc.archive)
+	router.POST("/readme/import", rc.importReadme)
+	router.POST("/readme/spec", rc.createReadmeFromSpec)
+	router.GET("/readme/spec/schema", rc.getSpecSchema)
+	router.GET("/readme/:id", rc.getReadme)
+	router.DELETE("/readme/:id", rc.deleteReadme)
+	router.PUT("/readme/:id/header", rc.addHeader)
+	router.PUT("/readme/:id/paragraph", rc.addParagraph)
+	router.PUT("/readme/:id/code", rc.addCode)
+	router.PUT("/readme/:id/blockquote", rc.addBlockquote)
+	router.PUT("/readme/:id/link", rc.addLink)
+	router.PUT("/readme/:id/image", rc.addImage)
+	router.PUT("/readme/:id/table", rc.addTable)
+	router.PUT("/readme/:id/list", rc.addList)
+	router.PUT("/readme/:id/tasklist", rc.addTaskList)
+	router.PUT("/readme/:id/snippet", rc.addSnippet)
+	router.GET("/readme/:id/element/:elementId", rc.getElement)
+	router.PUT("/readme/:id/element/:elementId", rc.updateElement)
+	router.DELETE("/readme/:id/element/:elementId", rc.deleteElement)
+	router.POST("/readme/:id/element/:elementId/move", rc.moveElement)
+	router.POST("/readme/:id/element/:elementId/item/:itemId/toggle", rc.toggleTask)
+	router.GET("/readme/:id/revisions", rc.listRevisions)
+	router.GET("/readme/:id/revisions/:revision", rc.getRevision)
+	router.POST("/readme/:id/revisions/:revision/rollback", rc.rollbackRevision)
+	router.GET("/readme/:id/diff", rc.diffReadme)
+	router.POST("/readme/:id/batch", rc.applyBatch)
+	router.GET("/readme/:id/variables", rc.getVariables)
+	router.PUT("/readme/:id/variables", rc.setVariables)
+	router.DELETE("/readme/:id/variables", rc.deleteVariables)
+	router.POST("/readme/:id/undo", rc.undo)
+	router.POST("/readme/:id/redo", rc.redo)
+	router.GET("/readme/:id/file", rc.downloadReadmeFile)
+	router.POST("/readme/:id/file", rc.exportReadmeFile)
+	router.GET("/readme/:id/events", rc.streamEvents)
+	router.GET("/readme/:id/session", rc.collabSession)
+	router.GET("/readme/:id/html", rc.getReadmeHTML)
+	router.GET("/preview/:id", rc.previewReadme)
+	router.GET("/template", rc.listTemplates)
+	router.POST("/template", rc.createTemplate)
+	router.GET("/template/:name", rc.getTemplate)
+	router.PUT("/template/:name", rc.updateTemplate)
+	router.DELETE("/template/:name", rc.deleteTemplate)
+	router.GET("/snippet", rc.listSnippets)
+	router.POST("/snippet", rc.createSnippet)
+	router.GET("/snippet/usage", rc.snippetUsageReport)
+	router.GET("/snippet/:name", rc.getSnippet)
+	router.PUT("/snippet/:name", rc.updateSnippet)
+	router.DELETE("/snippet/:name", rc.deleteSnippet)
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	return router
 }
@@ -101,7 +249,25 @@ func setupRouter() *gin.Engine {
 // @host      localhost:8080
 // @BasePath  /
 func main() {
-	router := setupRouter()
+	storeKind := flag.String("store", "memory", "where readmes are kept: memory, file or sqlite")
+	dataPath := flag.String("data", "data", "directory used by the file and sqlite stores")
+	exportPath := flag.String("export", "", "directory readmes are exported to, POST /readme/{id}/file streams the file back when empty")
+	flag.Parse()
+
+	store, err := newReadmeStore(*storeKind, *dataPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var options []func(rc *readmeController)
+	if *exportPath != "" {
+		if err := os.MkdirAll(*exportPath, 0755); err != nil {
+			log.Fatal(err)
+		}
+		options = append(options, withExportRoot(*exportPath))
+	}
+
+	router := setupRouter(store, options...)
 
 	router.Run("localhost:8080")
 }
@@ -112,10 +278,13 @@ func main() {
 // @Accept json
 // @Produce json
 // @Param	name	query	string	false	"pass a value to create a user defined readmeId"
+// @Param	template	query	string	false	"start from the placeholder elements of this template"
+// @Param	X-Author	header	string	false	"who made the change"
 // @Success	201		{object}	HttpMessage	"returns a message with the readmeId"
+// @Failure	404		{object}	HttpErrorMessage	"could not find template"
 // @Failure	409		{object}	HttpErrorMessage	"Readme already exists"
 // @Router	/readme	[post]
-func createReadme(c *gin.Context) {
+func (rc *readmeController) createReadme(c *gin.Context) {
 	var readmeId = ""
 
 	if c.Query("name") == "" {
@@ -124,77 +293,298 @@ func createReadme(c *gin.Context) {
 		readmeId = c.Query("name")
 	}
 
-	if len(readmeDB[readmeId]) >= 1 {
+	readme, operation := Readme{NAME: readmeId, ELEMENTS: []Element{}}, "create"
+	if name := c.Query("template"); name != "" {
+		template, err := rc.template(name)
+		if err != nil {
+			respondTemplateError(c, err)
+			return
+		}
+		// templates are written with variables, the readme renders once they are set
+		readme.ELEMENTS, readme.VARIABLES, operation = templateElements(template), map[string]string{}, "create from template "+name
+	}
+
+	if err := rc.create(c, readme, operation); err != nil {
+		respondCreateError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusCreated, HttpMessage{MESSAGE: readmeId})
+}
+
+// create stores the new readme, its elements recorded as its first revision. Every snippet it includes has to exist.
+func (rc *readmeController) create(c *gin.Context, readme Readme, operation string) error {
+	rc.snippets.RLock()
+	defer rc.snippets.RUnlock()
+
+	if err := rc.missingSnippets(snippetNames(readme.ELEMENTS)); err != nil {
+		return err
+	}
+
+	readme.CREATED_AT = time.Now().UTC()
+	fillTaskIds(readme.ELEMENTS)
+	readme.recordRevision(author(c), operation, readme.CREATED_AT)
+
+	return rc.store.Create(readme)
+}
+
+// respondCreateError is respondStoreError for creating a readme, where an existing readme is a conflict
+func respondCreateError(c *gin.Context, err error) {
+	if errors.Is(err, ErrReadmeExists) {
 		c.IndentedJSON(http.StatusConflict, HttpErrorMessage{MESSAGE: "Readme with that id already exists"})
 		return
 	}
 
-	readmeDB[readmeId] = append(readmeDB[readmeId], "")
+	respondStoreError(c, err)
+}
 
-	c.IndentedJSON(http.StatusCreated, HttpMessage{MESSAGE: readmeId})
+// ListReadmes godoc
+// @Summary Lists readmes
+// @Description Lists readmes ordered by name, a page at a time
+// @Accept json
+// @Produce json
+// @Param	prefix	query	string	false	"only readmes whose name starts with prefix"
+// @Param	created_after	query	string	false	"only readmes created after this RFC 3339 time"
+// @Param	created_before	query	string	false	"only readmes created before this RFC 3339 time"
+// @Param	page	query	int	false	"page to return, starting at 1"
+// @Param	page_size	query	int	false	"readmes per page, between 1 and 100, defaults to 20"
+// @Success	200	{object}	ReadmeListResponse	"a page of readmes"
+// @Failure 400	{object}	HttpErrorMessage	"incorrect query params"
+// @Router	/readme	[get]
+func (rc *readmeController) listReadmes(c *gin.Context) {
+	prefix := c.Query("prefix")
+	var createdAfter, createdBefore time.Time
+	page, pageSize := 1, 20
+
+	for param, value := range map[string]*time.Time{"created_after": &createdAfter, "created_before": &createdBefore} {
+		if c.Query(param) == "" {
+			continue
+		}
+
+		parsed, err := time.Parse(time.RFC3339, c.Query(param))
+		if err != nil {
+			c.IndentedJSON(http.StatusBadRequest, HttpErrorMessage{MESSAGE: param + " should be an RFC 3339 time"})
+			return
+		}
+		*value = parsed
+	}
+
+	for param, value := range map[string]*int{"page": &page, "page_size": &pageSize} {
+		if c.Query(param) == "" {
+			continue
+		}
+
+		parsed, err := strconv.Atoi(c.Query(param))
+		if err != nil || parsed < 1 || (param == "page_size" && parsed > 100) {
+			c.IndentedJSON(http.StatusBadRequest, HttpErrorMessage{MESSAGE: param + " is out of range"})
+			return
+		}
+		*value = parsed
+	}
+
+	summaries, err := rc.store.List()
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	matching := []ReadmeSummary{}
+	for _, summary := range summaries {
+		if !strings.HasPrefix(summary.NAME, prefix) {
+			continue
+		}
+		if !createdAfter.IsZero() && !summary.CREATED_AT.After(createdAfter) {
+			continue
+		}
+		if !createdBefore.IsZero() && !summary.CREATED_AT.Before(createdBefore) {
+			continue
+		}
+		matching = append(matching, summary)
+	}
+
+	start := (page - 1) * pageSize
+	if start > len(matching) {
+		start = len(matching)
+	}
+	end := start + pageSize
+	if end > len(matching) {
+		end = len(matching)
+	}
+
+	c.IndentedJSON(http.StatusOK, ReadmeListResponse{READMES: matching[start:end], TOTAL: len(matching), PAGE: page, PAGE_SIZE: pageSize})
 }
 
-// CreateReadmeFile godoc
-// @Summary Creates markdown file
-// @Description From all of your previous operations takes the readme and generates the markdown file
+// DeleteReadme godoc
+// @Summary Deletes a readme
 // @Accept json
 // @Produce json
 // @Param	id	path	string	true	"readme id"
-// @Success 200
-// @Router 	/readme/{id}/file	[post]
-func createReadmeFile(c *gin.Context) {
+// @Success	200	{object}	HttpMessage	"returns the deleted readmeId"
+// @Failure 404	{object}	HttpErrorMessage	"could not find readme"
+// @Router	/readme/{id}	[delete]
+func (rc *readmeController) deleteReadme(c *gin.Context) {
 	readmeId := c.Param("id")
-	f, err := os.Create("/tmp/readme.md")
-	check(err)
 
-	//write buffer
-	wr := bufio.NewWriter(f)
+	unlock := rc.sequence.Lock(readmeId)
+	defer unlock()
+
+	if err := rc.store.Delete(readmeId); err != nil {
+		respondStoreError(c, err)
+		return
+	}
 
-	var lines = readmeDB[readmeId]
+	rc.events.Close(readmeId, ReadmeEvent{OPERATION: "delete", CHANGE: "deleted"})
+	rc.sessions.end(readmeId, SessionMessage{TYPE: "deleted", AUTHOR: author(c)})
 
-	for _, line := range lines {
-		if _, err := wr.Write([]byte(line)); err != nil {
-			panic(err)
+	c.IndentedJSON(http.StatusOK, HttpMessage{MESSAGE: readmeId})
+}
+
+// readmeFileName turns the readme name into a safe markdown file name, anything but letters,
+// digits, dots, dashes and underscores becomes a dash
+func readmeFileName(readmeId string) string {
+	return fileName(readmeId, true)
+}
+
+// fileName keeps the letters and digits of the readme name, only ascii ones when asked, and turns
+// everything else but dots, dashes and underscores into a dash
+func fileName(readmeId string, ascii bool) string {
+	name := strings.Trim(strings.Map(func(r rune) rune {
+		if (!ascii || r <= unicode.MaxASCII) && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '-' || r == '_') {
+			return r
+		}
+		return '-'
+	}, readmeId), ".-")
+
+	if name == "" {
+		name = "readme"
+	}
+
+	return name + ".md"
+}
+
+// readmeContentDisposition names the downloaded file after the readme. Clients that only read
+// filename get the ascii name, names in other scripts are also sent as an RFC 5987 filename*.
+func readmeContentDisposition(readmeId string) string {
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": readmeFileName(readmeId)})
+
+	if name := fileName(readmeId, false); name != readmeFileName(readmeId) {
+		disposition += "; filename*=UTF-8''" + rfc5987Escape(name)
+	}
+
+	return disposition
+}
+
+// rfc5987Escape percent encodes every byte of value that is not an RFC 5987 attr-char
+func rfc5987Escape(value string) string {
+	var escaped strings.Builder
+	for i := 0; i < len(value); i++ {
+		b := value[i]
+		if b < utf8.RuneSelf && (unicode.IsLetter(rune(b)) || unicode.IsDigit(rune(b)) || strings.IndexByte("!#$&+-.^_`|~", b) >= 0) {
+			escaped.WriteByte(b)
+			continue
 		}
+		fmt.Fprintf(&escaped, "%%%02X", b)
+	}
+
+	return escaped.String()
+}
+
+// DownloadReadmeFile godoc
+// @Summary Downloads the markdown file
+// @Description From all of your previous operations takes the readme and streams back the markdown file, named after the readme
+// @Accept json
+// @Produce text/markdown
+// @Param	id	path	string	true	"readme id"
+// @Success 200	{string}	string	"the markdown file"
+// @Failure 404	{object}	HttpErrorMessage	"could not find readme"
+// @Failure 422	{object}	HttpErrorMessage	"the elements use undefined variables"
+// @Router 	/readme/{id}/file	[get]
+func (rc *readmeController) downloadReadmeFile(c *gin.Context) {
+	readmeId := c.Param("id")
+	readme, err := rc.store.Get(readmeId)
+	if err != nil {
+		respondStoreError(c, err)
+		return
 	}
 
-	if err = wr.Flush(); err != nil {
-		panic(err)
+	readme, err = rc.render(readme)
+	if err != nil {
+		respondStoreError(c, err)
+		return
 	}
+
+	c.Header("Content-Disposition", readmeContentDisposition(readme.NAME))
+	c.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(renderReadme(readme)))
 }
 
 // GetReadme godoc
 // @Summary Returns a readme
+// @Description By default returns the markdown string of every element, format=elements returns the typed elements and format=markdown the rendered document
 // @Accept json
 // @Produce json
 // @Param	id	path	string	true	"readme id"
+// @Param	format	query	string	false	"elements or markdown"
 // @Success	200	{array}		string	"list of markdown strings"
 // @Failure 404	{object}	HttpErrorMessage	"could not find readme"
+// @Failure 400	{object}	HttpErrorMessage	"unknown format"
+// @Failure 422	{object}	HttpErrorMessage	"the elements use undefined variables"
 // @Router	/readme/{id}		[get]
-func getReadme(c *gin.Context) {
+func (rc *readmeController) getReadme(c *gin.Context) {
 	readmeId := c.Param("id")
+	format := c.Query("format")
+
+	if !validFormat(format) {
+		c.IndentedJSON(http.StatusBadRequest, HttpErrorMessage{MESSAGE: "format should be elements or markdown"})
+		return
+	}
+
+	readme, err := rc.store.Get(readmeId)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	rc.respondReadme(c, readme, format)
+}
+
+func validFormat(format string) bool {
+	return format == "" || format == "elements" || format == "markdown"
+}
+
+// respondReadme writes the readme in the format asked for by the format query, the elements
+// format returns them as written and the markdown formats with snippets and variables filled in
+func (rc *readmeController) respondReadme(c *gin.Context, readme Readme, format string) {
+	if format == "elements" {
+		c.IndentedJSON(http.StatusOK, readme)
+		return
+	}
 
-	if len(readmeDB[readmeId]) < 1 {
-		c.IndentedJSON(http.StatusNotFound, HttpErrorMessage{MESSAGE: "could not find readme"})
+	readme, err := rc.render(readme)
+	if err != nil {
+		respondStoreError(c, err)
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, readmeDB[readmeId])
+	switch format {
+	case "markdown":
+		c.IndentedJSON(http.StatusOK, HttpMessage{MESSAGE: renderReadme(readme)})
+	default:
+		c.IndentedJSON(http.StatusOK, renderElements(readme.ELEMENTS))
+	}
 }
 
 // change to read file from s3
-func decodeReadme(c *gin.Context) {
+func (rc *readmeController) decodeReadme(c *gin.Context) {
 	readmeId := c.Param("id")
 
-	if len(readmeDB[readmeId]) < 1 {
-		c.IndentedJSON(http.StatusNotFound, HttpErrorMessage{MESSAGE: "could not find readme"})
+	readme, err := rc.store.Get(readmeId)
+	if err != nil {
+		respondStoreError(c, err)
 		return
 	}
 
-	readme := readmeDB[readmeId]
-
 	currentReadmeDecoded := ``
-	for _, line := range readme {
+	for _, line := range renderElements(readme.ELEMENTS) {
 		decodedReadmeLine, err := strconv.Unquote(`"` + line + `"`)
 		if err != nil {
 			panic(err)
@@ -212,11 +602,16 @@ func decodeReadme(c *gin.Context) {
 // @Produce json
 // @Param	id	path	string	true	"readme id"
 // @Param	addHeader	body	AddHeaderRequest	true	"request body for header"
-// @Success	200	{object}	HttpMessage	"returns the header markdown string"
+// @Param	index	query	int	false	"insert the element at this index"
+// @Param	before	query	string	false	"insert the element before this element id"
+// @Param	after	query	string	false	"insert the element after this element id"
+// @Param	X-Author	header	string	false	"who made the change"
+// @Success	200	{object}	ElementResponse	"returns the header markdown string"
 // @Failure 404	{object}	HttpErrorMessage	"could not find readme"
 // @Failure 400	{object}	HttpErrorMessage	"incorrect request body"
+// @Failure 400	{object}	HttpErrorMessage	"header_type should be one of SMALL_HEADING, MEDIUM_HEADING, LARGE_HEADING"
 // @Router	/readme/{id}/header	[put]
-func addHeader(c *gin.Context) {
+func (rc *readmeController) addHeader(c *gin.Context) {
 	readmeId := c.Param("id")
 	var addHeaderRequest AddHeaderRequest
 
@@ -225,18 +620,12 @@ func addHeader(c *gin.Context) {
 		return
 	}
 
-	if len(readmeDB[readmeId]) < 1 {
-		c.IndentedJSON(http.StatusNotFound, HttpErrorMessage{MESSAGE: "could not find readme"})
+	if err := addHeaderRequest.validate(); err != nil {
+		respondStoreError(c, err)
 		return
 	}
 
-	headerMarkdown := headingSyntaxMap[addHeaderRequest.HEADER_TYPE]
-
-	createdString := headerMarkdown + addHeaderRequest.VALUE + "\n"
-
-	readmeDB[readmeId] = append(readmeDB[readmeId], createdString)
-
-	c.IndentedJSON(http.StatusOK, HttpMessage{MESSAGE: createdString})
+	rc.addElement(c, readmeId, addHeaderRequest.element())
 }
 
 // AddParagraph godoc
@@ -246,29 +635,26 @@ func addHeader(c *gin.Context) {
 // @Produce json
 // @Param	id	path	string	true	"readme id"
 // @Param	paragraph	query	string	true	"paragraph you want to add to the readme"
-// @Success	200	{object}	HttpMessage	"returns an paragraph markdown string"
+// @Param	index	query	int	false	"insert the element at this index"
+// @Param	before	query	string	false	"insert the element before this element id"
+// @Param	after	query	string	false	"insert the element after this element id"
+// @Param	X-Author	header	string	false	"who made the change"
+// @Success	200	{object}	ElementResponse	"returns an paragraph markdown string"
 // @Failure 404	{object}	HttpErrorMessage	"could not find readme"
 // @Failure 400	{object}	HttpErrorMessage	"paragraph param cannot be empty"
 // @Router	/readme/{id}/paragraph	[put]
-func addParagraph(c *gin.Context) {
+func (rc *readmeController) addParagraph(c *gin.Context) {
 	readmeId := c.Param("id")
 	paragraph := c.Query("paragraph")
 
-	if len(readmeDB[readmeId]) < 1 {
-		c.IndentedJSON(http.StatusNotFound, HttpErrorMessage{MESSAGE: "could not find readme"})
-		return
-	}
-
 	if strings.TrimSpace(paragraph) == "" {
 		c.IndentedJSON(http.StatusBadRequest, HttpErrorMessage{MESSAGE: "paragraph cannot be empty"})
 		return
 	}
 
-	paragraph = paragraph + "\n"
+	element := Element{TYPE: ParagraphElement, VALUE: paragraph}
 
-	readmeDB[readmeId] = append(readmeDB[readmeId], paragraph)
-
-	c.IndentedJSON(http.StatusOK, HttpMessage{MESSAGE: paragraph})
+	rc.addElement(c, readmeId, element)
 }
 
 // AddCode godoc
@@ -278,12 +664,16 @@ func addParagraph(c *gin.Context) {
 // @Produce json
 // @Param	id	path	string	true	"readme id"
 // @Param	codeRequest	body	AddCodeRequest	true	"request for code markdown"
-// @Success	200	{object}	HttpMessage	"returns the created code markdown string"
+// @Param	index	query	int	false	"insert the element at this index"
+// @Param	before	query	string	false	"insert the element before this element id"
+// @Param	after	query	string	false	"insert the element after this element id"
+// @Param	X-Author	header	string	false	"who made the change"
+// @Success	200	{object}	ElementResponse	"returns the created code markdown string"
 // @Failure 404	{object}	HttpErrorMessage	"could not find readme"
 // @Failure 400	{object}	HttpErrorMessage	"incorrect request body"
 // @Failure 400	{object}	HttpErrorMessage	"the code language is not suppored"
 // @Router	/readme/{id}/code	[put]
-func addCode(c *gin.Context) {
+func (rc *readmeController) addCode(c *gin.Context) {
 	readmeId := c.Param("id")
 	var addCodeRequest AddCodeRequest
 
@@ -292,17 +682,8 @@ func addCode(c *gin.Context) {
 		return
 	}
 
-	if len(readmeDB[readmeId]) < 1 {
-		c.IndentedJSON(http.StatusNotFound, HttpErrorMessage{MESSAGE: "could not find readme"})
-		return
-	}
-
 	if codeLanguageMap[addCodeRequest.CODE_LANGUAGE] {
-		createdCodeString := "```" + addCodeRequest.CODE_LANGUAGE + "\n " + addCodeRequest.VALUE + "```" + "\n"
-
-		readmeDB[readmeId] = append(readmeDB[readmeId], createdCodeString)
-
-		c.IndentedJSON(http.StatusOK, HttpMessage{MESSAGE: createdCodeString})
+		rc.addElement(c, readmeId, addCodeRequest.element())
 		return
 	}
 
@@ -316,11 +697,15 @@ func addCode(c *gin.Context) {
 // @Produce	json
 // @Param	id	path	string	true	"readme id"
 // @Param	paragraph	query	string	true	"string for paragraph markdown"
-// @Success	200	{object}	HttpMessage	"returns created markdown blockquote string"
+// @Param	index	query	int	false	"insert the element at this index"
+// @Param	before	query	string	false	"insert the element before this element id"
+// @Param	after	query	string	false	"insert the element after this element id"
+// @Param	X-Author	header	string	false	"who made the change"
+// @Success	200	{object}	ElementResponse	"returns created markdown blockquote string"
 // @Failure 404	{object}	HttpErrorMessage	"could not find readme"
 // @Failure 400	{object}	HttpErrorMessage	"blockquote can not be empty"
 // @Router	/readme/{id}/blockquote	[put]
-func addBlockquote(c *gin.Context) {
+func (rc *readmeController) addBlockquote(c *gin.Context) {
 	readmeId := c.Param("id")
 	message := c.Query("blockquote")
 
@@ -329,16 +714,9 @@ func addBlockquote(c *gin.Context) {
 		return
 	}
 
-	createdBlockquote := "> " + message + "\n"
+	element := Element{TYPE: BlockquoteElement, VALUE: message}
 
-	if len(readmeDB[readmeId]) < 1 {
-		c.IndentedJSON(http.StatusNotFound, HttpErrorMessage{MESSAGE: "could not find readme"})
-		return
-	}
-
-	readmeDB[readmeId] = append(readmeDB[readmeId], createdBlockquote)
-
-	c.IndentedJSON(http.StatusOK, HttpMessage{MESSAGE: createdBlockquote})
+	rc.addElement(c, readmeId, element)
 }
 
 // AddLink godoc
@@ -348,11 +726,15 @@ func addBlockquote(c *gin.Context) {
 // @Produce json
 // @Param	id	path	string	true	"readme id"
 // @Param	addLinkRequest	body	AddLinkRequest	true	"request for adding link"
-// @Success	200	{object}	HttpMessage	"returns created markdown link"
+// @Param	index	query	int	false	"insert the element at this index"
+// @Param	before	query	string	false	"insert the element before this element id"
+// @Param	after	query	string	false	"insert the element after this element id"
+// @Param	X-Author	header	string	false	"who made the change"
+// @Success	200	{object}	ElementResponse	"returns created markdown link"
 // @Failure 404	{object}	HttpErrorMessage	"could not find readme"
 // @Failure 400	{object}	HttpErrorMessage	"incorrect request body"
 // @Router	/readme/{id}/link	[put]
-func addLink(c *gin.Context) {
+func (rc *readmeController) addLink(c *gin.Context) {
 	readmeId := c.Param("id")
 	var addLinkRequest AddLinkRequest
 
@@ -361,16 +743,7 @@ func addLink(c *gin.Context) {
 		return
 	}
 
-	if len(readmeDB[readmeId]) < 1 {
-		c.IndentedJSON(http.StatusNotFound, HttpErrorMessage{MESSAGE: "could not find readme"})
-		return
-	}
-
-	createdLink := "[" + addLinkRequest.DESCRIPTION + "]" + "(" + addLinkRequest.LINK + ")" + "\n"
-
-	readmeDB[readmeId] = append(readmeDB[readmeId], createdLink)
-
-	c.IndentedJSON(http.StatusOK, HttpMessage{MESSAGE: createdLink})
+	rc.addElement(c, readmeId, addLinkRequest.element(LinkElement))
 }
 
 // AddImage godoc
@@ -380,11 +753,15 @@ func addLink(c *gin.Context) {
 // @Produce json
 // @Param	id	path	string	true	"readme id"
 // @Param	addLinkRequest	body	AddLinkRequest	true	"request body for adding image"
-// @Success	200	{object}	HttpMessage	"returns created markdown image link"
+// @Param	index	query	int	false	"insert the element at this index"
+// @Param	before	query	string	false	"insert the element before this element id"
+// @Param	after	query	string	false	"insert the element after this element id"
+// @Param	X-Author	header	string	false	"who made the change"
+// @Success	200	{object}	ElementResponse	"returns created markdown image link"
 // @Failure 404	{object}	HttpErrorMessage	"could not find readme"
 // @Failure 400	{object}	HttpErrorMessage	"incorrect request body"
 // @Router	/readme/{id}/image	[put]
-func addImage(c *gin.Context) {
+func (rc *readmeController) addImage(c *gin.Context) {
 	readmeId := c.Param("id")
 	var addImageRequest AddLinkRequest
 
@@ -393,16 +770,7 @@ func addImage(c *gin.Context) {
 		return
 	}
 
-	if len(readmeDB[readmeId]) < 1 {
-		c.IndentedJSON(http.StatusNotFound, HttpErrorMessage{MESSAGE: "could not find readme"})
-		return
-	}
-
-	createdImage := "![" + addImageRequest.DESCRIPTION + "]" + "(" + addImageRequest.LINK + ")" + "\n"
-
-	readmeDB[readmeId] = append(readmeDB[readmeId], createdImage)
-
-	c.IndentedJSON(http.StatusOK, HttpMessage{MESSAGE: createdImage})
+	rc.addElement(c, readmeId, addImageRequest.element(ImageElement))
 }
 
 // AddTable godoc
@@ -412,11 +780,15 @@ func addImage(c *gin.Context) {
 // @Produce json
 // @Param	id	path	string	true	"readme id"
 // @Param	addTableRequest	body	AddTableRequest	true	"request table body"
-// @Success	200	{object}	HttpMessage	"returns table markdown string with values inserted"
+// @Param	index	query	int	false	"insert the element at this index"
+// @Param	before	query	string	false	"insert the element before this element id"
+// @Param	after	query	string	false	"insert the element after this element id"
+// @Param	X-Author	header	string	false	"who made the change"
+// @Success	200	{object}	ElementResponse	"returns table markdown string with values inserted"
 // @Failure 404	{object}	HttpErrorMessage	"could not find readme"
 // @Failure 400	{object}	HttpErrorMessage	"incorrect request body"
 // @Router	/readme/{id}/table	[put]
-func addTable(c *gin.Context) {
+func (rc *readmeController) addTable(c *gin.Context) {
 	readmeId := c.Param("id")
 	var addTableRequest AddTableRequest
 
@@ -425,45 +797,5 @@ func addTable(c *gin.Context) {
 		return
 	}
 
-	if len(readmeDB[readmeId]) < 1 {
-		c.IndentedJSON(http.StatusNotFound, HttpErrorMessage{MESSAGE: "could not find readme"})
-		return
-	}
-
-	largestColumn := 0
-	createdTableString := `|`
-
-	//create column label row
-	for _, cName := range addTableRequest.COLUMN_NAMES {
-		if len(addTableRequest.COLUMN_VALUES[cName]) > largestColumn {
-			largestColumn = len(addTableRequest.COLUMN_VALUES[cName])
-		}
-		createdTableString = createdTableString + cName + `|`
-	}
-
-	createdTableString = createdTableString + "\n" + `|`
-
-	// create separated between column label and column values
-	for i := 0; i < len(addTableRequest.COLUMN_NAMES); i++ {
-		createdTableString = createdTableString + ` --- |`
-	}
-
-	createdTableString = createdTableString + "\n"
-
-	// values in each column
-	for i := 0; i < largestColumn; i++ {
-		currentString := `|`
-		for _, column_name := range addTableRequest.COLUMN_NAMES {
-			if i < len(addTableRequest.COLUMN_VALUES[column_name]) {
-				currentString = currentString + addTableRequest.COLUMN_VALUES[column_name][i] + `|`
-			} else {
-				currentString = currentString + " " + `|`
-			}
-		}
-		createdTableString = createdTableString + currentString + "\n"
-	}
-
-	readmeDB[readmeId] = append(readmeDB[readmeId], createdTableString)
-
-	c.IndentedJSON(http.StatusOK, HttpMessage{MESSAGE: createdTableString})
+	rc.addElement(c, readmeId, addTableRequest.element())
 }
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// addElement validates the element, gives it its id, inserts it where the index, before or after
+// query asks (the end of the readme by default) and responds with the rendered element, lists are sent back
+// with their task ids
+func (rc *readmeController) addElement(c *gin.Context, readmeId string, element Element) {
+	if err := validateElement(element); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	position, err := positionFromQuery(c)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	element.ID = uuid.NewString()
+	// the task ids are given here rather than when the readme is saved so they can be sent back
+	fillTaskIds([]Element{element})
+
+	_, err = rc.update(c, readmeId, "add "+string(element.TYPE), func(readme *Readme) error {
+		index, err := position.index(readme)
+		if err != nil {
+			return err
+		}
+
+		readme.insertAt(index, element)
+		return nil
+	})
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, ElementResponse{ID: element.ID, MESSAGE: renderElement(element), LIST: element.LIST})
+}
+
+// GetElement godoc
+// @Summary Returns an element
+// @Description Returns the typed element so it can be edited and sent back to the update endpoint
+// @Accept json
+// @Produce json
+// @Param	id	path	string	true	"readme id"
+// @Param	elementId	path	string	true	"element id"
+// @Success	200	{object}	Element	"the element"
+// @Failure 404	{object}	HttpErrorMessage	"could not find readme or element"
+// @Router	/readme/{id}/element/{elementId}	[get]
+func (rc *readmeController) getElement(c *gin.Context) {
+	readmeId := c.Param("id")
+	elementId := c.Param("elementId")
+
+	readme, err := rc.store.Get(readmeId)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	index, err := readme.indexOf(elementId)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, readme.ELEMENTS[index])
+}
+
+// UpdateElement godoc
+// @Summary Replaces an element
+// @Description Replaces the element keeping its id and position, the type may change
+// @Accept json
+// @Produce json
+// @Param	id	path	string	true	"readme id"
+// @Param	elementId	path	string	true	"element id"
+// @Param	element	body	Element	true	"the new element"
+// @Param	X-Author	header	string	false	"who made the change"
+// @Success	200	{object}	ElementResponse	"returns the element markdown string"
+// @Failure 404	{object}	HttpErrorMessage	"could not find readme or element"
+// @Failure 400	{object}	HttpErrorMessage	"incorrect request body"
+// @Failure 422	{object}	HttpErrorMessage	"the element includes a snippet that doesn't exist"
+// @Router	/readme/{id}/element/{elementId}	[put]
+func (rc *readmeController) updateElement(c *gin.Context) {
+	readmeId := c.Param("id")
+	elementId := c.Param("elementId")
+	var element Element
+
+	if err := c.BindJSON(&element); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, HttpErrorMessage{MESSAGE: "incorrect request body, should be Element body"})
+		return
+	}
+
+	if err := validateElement(element); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	element.ID = elementId
+	fillTaskIds([]Element{element})
+
+	_, err := rc.update(c, readmeId, "update "+elementId, func(readme *Readme) error {
+		index, err := readme.indexOf(elementId)
+		if err != nil {
+			return err
+		}
+
+		readme.ELEMENTS[index] = element
+		return nil
+	})
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, ElementResponse{ID: element.ID, MESSAGE: renderElement(element), LIST: element.LIST})
+}
+
+// DeleteElement godoc
+// @Summary Deletes an element
+// @Accept json
+// @Produce json
+// @Param	id	path	string	true	"readme id"
+// @Param	elementId	path	string	true	"element id"
+// @Param	X-Author	header	string	false	"who made the change"
+// @Success	200	{object}	HttpMessage	"returns the deleted element id"
+// @Failure 404	{object}	HttpErrorMessage	"could not find readme or element"
+// @Router	/readme/{id}/element/{elementId}	[delete]
+func (rc *readmeController) deleteElement(c *gin.Context) {
+	readmeId := c.Param("id")
+	elementId := c.Param("elementId")
+
+	_, err := rc.update(c, readmeId, "delete "+elementId, func(readme *Readme) error {
+		index, err := readme.indexOf(elementId)
+		if err != nil {
+			return err
+		}
+
+		readme.ELEMENTS = append(readme.ELEMENTS[:index], readme.ELEMENTS[index+1:]...)
+		return nil
+	})
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, HttpMessage{MESSAGE: elementId})
+}
+
+// MoveElement godoc
+// @Summary Moves an element
+// @Description Moves the element before or after another element, or to an index. Exactly one of before, after or index should be set
+// @Accept json
+// @Produce json
+// @Param	id	path	string	true	"readme id"
+// @Param	elementId	path	string	true	"element id"
+// @Param	moveElementRequest	body	MoveElementRequest	true	"where to move the element"
+// @Param	X-Author	header	string	false	"who made the change"
+// @Success	200	{array}		string	"element ids in their new order"
+// @Failure 404	{object}	HttpErrorMessage	"could not find readme or element"
+// @Failure 400	{object}	HttpErrorMessage	"incorrect request body"
+// @Router	/readme/{id}/element/{elementId}/move	[post]
+func (rc *readmeController) moveElement(c *gin.Context) {
+	readmeId := c.Param("id")
+	elementId := c.Param("elementId")
+	var moveElementRequest MoveElementRequest
+
+	if err := c.BindJSON(&moveElementRequest); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, HttpErrorMessage{MESSAGE: "incorrect request body, should be MoveElementRequest body"})
+		return
+	}
+
+	var elementIds []string
+	_, err := rc.update(c, readmeId, "move "+elementId, func(readme *Readme) error {
+		if err := moveElement(readme, elementId, moveElementRequest); err != nil {
+			return err
+		}
+
+		elementIds = make([]string, len(readme.ELEMENTS))
+		for i, element := range readme.ELEMENTS {
+			elementIds[i] = element.ID
+		}
+		return nil
+	})
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, elementIds)
+}
+
+// positionFromQuery reads the optional index, before and after query params of the add endpoints
+func positionFromQuery(c *gin.Context) (MoveElementRequest, error) {
+	position := MoveElementRequest{BEFORE: c.Query("before"), AFTER: c.Query("after")}
+
+	if c.Query("index") != "" {
+		index, err := strconv.Atoi(c.Query("index"))
+		if err != nil {
+			return position, invalidRequestError{"index should be a number"}
+		}
+		position.INDEX = &index
+	}
+
+	if position.count() > 1 {
+		return position, invalidRequestError{"only one of before, after or index can be set"}
+	}
+
+	return position, nil
+}
+
+// count returns how many of before, after and index are set
+func (p MoveElementRequest) count() int {
+	count := 0
+	for _, set := range []bool{p.BEFORE != "", p.AFTER != "", p.INDEX != nil} {
+		if set {
+			count++
+		}
+	}
+
+	return count
+}
+
+// index returns where in the readme the position points to, the end of the readme when nothing is set
+func (p MoveElementRequest) index(readme *Readme) (int, error) {
+	switch {
+	case p.BEFORE != "":
+		return readme.indexOf(p.BEFORE)
+	case p.AFTER != "":
+		index, err := readme.indexOf(p.AFTER)
+		if err != nil {
+			return index, err
+		}
+		return index + 1, nil
+	case p.INDEX != nil:
+		if *p.INDEX < 0 || *p.INDEX > len(readme.ELEMENTS) {
+			return -1, invalidRequestError{"index is outside of the readme"}
+		}
+		return *p.INDEX, nil
+	}
+
+	return len(readme.ELEMENTS), nil
+}
+
+// moveElement takes the element out of the readme and puts it back where the request asks
+func moveElement(readme *Readme, elementId string, request MoveElementRequest) error {
+	if request.count() != 1 {
+		return invalidRequestError{"exactly one of before, after or index should be set"}
+	}
+
+	if request.BEFORE == elementId || request.AFTER == elementId {
+		return invalidRequestError{"an element cannot be moved relative to itself"}
+	}
+
+	index, err := readme.indexOf(elementId)
+	if err != nil {
+		return err
+	}
+
+	element := readme.ELEMENTS[index]
+	readme.ELEMENTS = append(readme.ELEMENTS[:index], readme.ELEMENTS[index+1:]...)
+
+	target, err := request.index(readme)
+	if err != nil {
+		return err
+	}
+
+	readme.insertAt(target, element)
+	return nil
+}
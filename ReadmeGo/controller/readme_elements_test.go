@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+// addParagraph adds a paragraph through the api and returns the new element id
+func addParagraph(t *testing.T, router *gin.Engine, readmeId string, paragraph string) string {
+	r := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/readme/"+readmeId+"/paragraph?paragraph="+paragraph, nil)
+	router.ServeHTTP(r, req)
+	require.Equal(t, http.StatusOK, r.Code)
+
+	var response ElementResponse
+	require.NoError(t, json.Unmarshal(r.Body.Bytes(), &response))
+	return response.ID
+}
+
+func getRenderedReadme(t *testing.T, router *gin.Engine, readmeId string) []string {
+	r := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/readme/"+readmeId, nil)
+	router.ServeHTTP(r, req)
+
+	var lines []string
+	require.NoError(t, json.Unmarshal(r.Body.Bytes(), &lines))
+	return lines
+}
+
+func TestGetElement(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+	r := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+	router.ServeHTTP(w, req1)
+
+	elementId := addParagraph(t, router, "1", "first")
+
+	req2, _ := http.NewRequest("GET", "/readme/1/element/"+elementId, nil)
+	router.ServeHTTP(r, req2)
+
+	require.JSONEq(t, string(`{"id": "`+elementId+`", "type": "paragraph", "value": "first"}`), r.Body.String())
+}
+
+func TestGetElementReturnsElementNotFound(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+	r := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+	router.ServeHTTP(w, req1)
+
+	req2, _ := http.NewRequest("GET", "/readme/1/element/INVALID", nil)
+	router.ServeHTTP(r, req2)
+
+	require.Equal(t, http.StatusNotFound, r.Code)
+	require.JSONEq(t, string(`{"message": "could not find element"}`), r.Body.String())
+}
+
+func TestUpdateElement(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+	r := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+	router.ServeHTTP(w, req1)
+
+	elementId := addParagraph(t, router, "1", "Instal")
+	addParagraph(t, router, "1", "after")
+
+	var elementRequest = []byte(`{
+		"type": "header",
+		"level": 2,
+		"value": "Install"
+	}`)
+
+	req2, _ := http.NewRequest("PUT", "/readme/1/element/"+elementId, bytes.NewBuffer(elementRequest))
+	router.ServeHTTP(r, req2)
+
+	require.JSONEq(t, string(`{"id": "`+elementId+`", "message": "## Install\n"}`), r.Body.String())
+	require.Equal(t, []string{"## Install\n", "after\n"}, getRenderedReadme(t, router, "1"))
+}
+
+func TestUpdateElementReturnsInvalidElement(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+	r := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+	router.ServeHTTP(w, req1)
+
+	elementId := addParagraph(t, router, "1", "first")
+
+	var elementRequest = []byte(`{
+		"type": "code",
+		"code_language": "cobol",
+		"value": "DISPLAY 'HI'"
+	}`)
+
+	req2, _ := http.NewRequest("PUT", "/readme/1/element/"+elementId, bytes.NewBuffer(elementRequest))
+	router.ServeHTTP(r, req2)
+
+	require.Equal(t, http.StatusBadRequest, r.Code)
+	require.JSONEq(t, string(`{"message": "Code language not supported"}`), r.Body.String())
+}
+
+func TestDeleteElement(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+	r := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+	router.ServeHTTP(w, req1)
+
+	addParagraph(t, router, "1", "first")
+	elementId := addParagraph(t, router, "1", "second")
+
+	req2, _ := http.NewRequest("DELETE", "/readme/1/element/"+elementId, nil)
+	router.ServeHTTP(r, req2)
+
+	require.Equal(t, http.StatusOK, r.Code)
+	require.Equal(t, []string{"first\n"}, getRenderedReadme(t, router, "1"))
+
+	r = httptest.NewRecorder()
+	req3, _ := http.NewRequest("DELETE", "/readme/1/element/"+elementId, nil)
+	router.ServeHTTP(r, req3)
+
+	require.Equal(t, http.StatusNotFound, r.Code)
+}
+
+func TestMoveElement(t *testing.T) {
+	tests := map[string]struct {
+		request func(ids []string) string
+		order   []string
+	}{
+		"before": {func(ids []string) string { return `{"before": "` + ids[0] + `"}` }, []string{"c\n", "a\n", "b\n"}},
+		"after":  {func(ids []string) string { return `{"after": "` + ids[0] + `"}` }, []string{"a\n", "c\n", "b\n"}},
+		"index":  {func(ids []string) string { return `{"index": 1}` }, []string{"a\n", "c\n", "b\n"}},
+		"end":    {func(ids []string) string { return `{"index": 2}` }, []string{"a\n", "b\n", "c\n"}},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			router := setupRouter(newMemoryStore())
+			w := httptest.NewRecorder()
+			r := httptest.NewRecorder()
+
+			req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+			router.ServeHTTP(w, req1)
+
+			ids := []string{addParagraph(t, router, "1", "a"), addParagraph(t, router, "1", "b"), addParagraph(t, router, "1", "c")}
+
+			req2, _ := http.NewRequest("POST", "/readme/1/element/"+ids[2]+"/move", bytes.NewBufferString(test.request(ids)))
+			router.ServeHTTP(r, req2)
+
+			require.Equal(t, http.StatusOK, r.Code)
+			require.Equal(t, test.order, getRenderedReadme(t, router, "1"))
+		})
+	}
+}
+
+func TestMoveElementReturnsInvalidMove(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+	router.ServeHTTP(w, req1)
+
+	first := addParagraph(t, router, "1", "a")
+	addParagraph(t, router, "1", "b")
+
+	for _, request := range []string{`{}`, `{"index": 5}`, `{"before": "` + first + `", "index": 0}`, `{"after": "` + first + `"}`} {
+		r := httptest.NewRecorder()
+		req2, _ := http.NewRequest("POST", "/readme/1/element/"+first+"/move", bytes.NewBufferString(request))
+		router.ServeHTTP(r, req2)
+
+		require.Equal(t, http.StatusBadRequest, r.Code, request)
+	}
+
+	require.Equal(t, []string{"a\n", "b\n"}, getRenderedReadme(t, router, "1"))
+}
+
+func TestAddElementAtPosition(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+	router.ServeHTTP(w, req1)
+
+	title := addParagraph(t, router, "1", "title")
+	addParagraph(t, router, "1", "body")
+
+	var linkRequest = []byte(`{
+		"link": "https://go.dev/doc/",
+		"description": "Go Dev"
+	}`)
+
+	r := httptest.NewRecorder()
+	req2, _ := http.NewRequest("PUT", "/readme/1/link?after="+title, bytes.NewBuffer(linkRequest))
+	router.ServeHTTP(r, req2)
+	require.Equal(t, http.StatusOK, r.Code)
+
+	r = httptest.NewRecorder()
+	req3, _ := http.NewRequest("PUT", "/readme/1/blockquote?blockquote=draft&index=0", nil)
+	router.ServeHTTP(r, req3)
+	require.Equal(t, http.StatusOK, r.Code)
+
+	r = httptest.NewRecorder()
+	req4, _ := http.NewRequest("PUT", "/readme/1/paragraph?paragraph=intro&before="+title, nil)
+	router.ServeHTTP(r, req4)
+	require.Equal(t, http.StatusOK, r.Code)
+
+	require.Equal(t, []string{"> draft\n", "intro\n", "title\n", "[Go Dev](https://go.dev/doc/)\n", "body\n"}, getRenderedReadme(t, router, "1"))
+}
+
+func TestAddElementReturnsInvalidPosition(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+	router.ServeHTTP(w, req1)
+
+	first := addParagraph(t, router, "1", "first")
+
+	for query, code := range map[string]int{
+		"index=one":                     http.StatusBadRequest,
+		"index=2":                       http.StatusBadRequest,
+		"index=0&after=" + first:        http.StatusBadRequest,
+		"after=INVALID":                 http.StatusNotFound,
+		"before=INVALID&after=" + first: http.StatusBadRequest,
+	} {
+		r := httptest.NewRecorder()
+		req2, _ := http.NewRequest("PUT", "/readme/1/paragraph?paragraph=second&"+query, nil)
+		router.ServeHTTP(r, req2)
+
+		require.Equal(t, code, r.Code, query)
+	}
+
+	require.Equal(t, []string{"first\n"}, getRenderedReadme(t, router, "1"))
+}
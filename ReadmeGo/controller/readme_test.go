@@ -2,9 +2,11 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -16,17 +18,25 @@ import (
 // router.PUT("/readme/:id/blockquote", addBlockquote)
 // router.PUT("/readme/:id/link", addLink)
 // router.PUT("/readme/:id/image", addImage)
-// router.POST("/readme/:id/file", createReadmeFile)
+// router.GET("/readme/:id/file", downloadReadmeFile)
+
+// requireElementResponse checks the markdown returned when an element is added, element ids are random
+func requireElementResponse(t *testing.T, markdown string, body string) {
+	var response ElementResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &response))
+	require.NotEmpty(t, response.ID)
+	require.Equal(t, markdown, response.MESSAGE)
+}
 
 func TestGetReadme(t *testing.T) {
-	router := setupRouter()
+	router := setupRouter(newMemoryStore())
 	r := httptest.NewRecorder()
 	w := httptest.NewRecorder()
 
 	req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
 	router.ServeHTTP(r, req1)
 
-	emptyReadme := string(`[""]`)
+	emptyReadme := string(`[]`)
 
 	req, _ := http.NewRequest("GET", "/readme/1", nil)
 	router.ServeHTTP(w, req)
@@ -35,7 +45,7 @@ func TestGetReadme(t *testing.T) {
 }
 
 func TestGetReadmeReturnsNotFoundReadme(t *testing.T) {
-	router := setupRouter()
+	router := setupRouter(newMemoryStore())
 	w := httptest.NewRecorder()
 
 	req, _ := http.NewRequest("GET", "/readme/INVALID", nil)
@@ -45,7 +55,7 @@ func TestGetReadmeReturnsNotFoundReadme(t *testing.T) {
 }
 
 func TestAddHeader(t *testing.T) {
-	router := setupRouter()
+	router := setupRouter(newMemoryStore())
 	w := httptest.NewRecorder()
 	r := httptest.NewRecorder()
 
@@ -60,11 +70,11 @@ func TestAddHeader(t *testing.T) {
 	req2, _ := http.NewRequest("PUT", "/readme/3/header", bytes.NewBuffer(headerRequest))
 	router.ServeHTTP(r, req2)
 
-	require.JSONEq(t, string(`{ "message": "### My first header\n"}`), r.Body.String())
+	requireElementResponse(t, "### My first header\n", r.Body.String())
 }
 
 func TestAddHeaderReturnsNotFoundReadme(t *testing.T) {
-	router := setupRouter()
+	router := setupRouter(newMemoryStore())
 	r := httptest.NewRecorder()
 
 	var headerRequest = []byte(`{
@@ -79,7 +89,7 @@ func TestAddHeaderReturnsNotFoundReadme(t *testing.T) {
 }
 
 func TestAddHeaderReturnsIncorrectRequestBody(t *testing.T) {
-	router := setupRouter()
+	router := setupRouter(newMemoryStore())
 	w := httptest.NewRecorder()
 	r := httptest.NewRecorder()
 
@@ -96,7 +106,7 @@ func TestAddHeaderReturnsIncorrectRequestBody(t *testing.T) {
 }
 
 func TestAddBlockquote(t *testing.T) {
-	router := setupRouter()
+	router := setupRouter(newMemoryStore())
 	w := httptest.NewRecorder()
 	r := httptest.NewRecorder()
 
@@ -106,11 +116,11 @@ func TestAddBlockquote(t *testing.T) {
 	req2, _ := http.NewRequest("PUT", "/readme/7/blockquote?blockquote=This is an important Quote", nil)
 	router.ServeHTTP(r, req2)
 
-	require.JSONEq(t, string(`{ "message": "> This is an important Quote\n" }`), r.Body.String())
+	requireElementResponse(t, "> This is an important Quote\n", r.Body.String())
 }
 
 func TestAddBlockquoteReturnsNotFoundReadme(t *testing.T) {
-	router := setupRouter()
+	router := setupRouter(newMemoryStore())
 	r := httptest.NewRecorder()
 
 	req1, _ := http.NewRequest("PUT", "/readme/10/blockquote?blockquote=This is an important Quote", nil)
@@ -120,7 +130,7 @@ func TestAddBlockquoteReturnsNotFoundReadme(t *testing.T) {
 }
 
 func TestAddBlockquoteReturnsEmptyBlockquote(t *testing.T) {
-	router := setupRouter()
+	router := setupRouter(newMemoryStore())
 	w := httptest.NewRecorder()
 	r := httptest.NewRecorder()
 
@@ -134,7 +144,7 @@ func TestAddBlockquoteReturnsEmptyBlockquote(t *testing.T) {
 }
 
 func TestAddLink(t *testing.T) {
-	router := setupRouter()
+	router := setupRouter(newMemoryStore())
 	w := httptest.NewRecorder()
 	r := httptest.NewRecorder()
 
@@ -149,11 +159,11 @@ func TestAddLink(t *testing.T) {
 	req2, _ := http.NewRequest("PUT", "/readme/12/link", bytes.NewBuffer(headerRequest))
 	router.ServeHTTP(r, req2)
 
-	require.JSONEq(t, string(`{ "message": "[Go Dev](https://go.dev/doc/)\n" }`), r.Body.String())
+	requireElementResponse(t, "[Go Dev](https://go.dev/doc/)\n", r.Body.String())
 }
 
 func TestAddLinkReturnsReadmeNotFound(t *testing.T) {
-	router := setupRouter()
+	router := setupRouter(newMemoryStore())
 	r := httptest.NewRecorder()
 
 	var headerRequest = []byte(`{
@@ -168,7 +178,7 @@ func TestAddLinkReturnsReadmeNotFound(t *testing.T) {
 }
 
 func TestAddLinkReturnsIncorrectRequestBody(t *testing.T) {
-	router := setupRouter()
+	router := setupRouter(newMemoryStore())
 	w := httptest.NewRecorder()
 	r := httptest.NewRecorder()
 
@@ -185,7 +195,7 @@ func TestAddLinkReturnsIncorrectRequestBody(t *testing.T) {
 }
 
 func TestAddImage(t *testing.T) {
-	router := setupRouter()
+	router := setupRouter(newMemoryStore())
 	w := httptest.NewRecorder()
 	r := httptest.NewRecorder()
 
@@ -200,11 +210,11 @@ func TestAddImage(t *testing.T) {
 	req2, _ := http.NewRequest("PUT", "/readme/14/link", bytes.NewBuffer(headerRequest))
 	router.ServeHTTP(r, req2)
 
-	require.JSONEq(t, string(`{ "message":"[Metamask](https://imgur.com/grhk1rU)\n" }`), r.Body.String())
+	requireElementResponse(t, "[Metamask](https://imgur.com/grhk1rU)\n", r.Body.String())
 }
 
 func TestAddImageReturnsReadmeNotFound(t *testing.T) {
-	router := setupRouter()
+	router := setupRouter(newMemoryStore())
 	r := httptest.NewRecorder()
 
 	var headerRequest = []byte(`{
@@ -219,7 +229,7 @@ func TestAddImageReturnsReadmeNotFound(t *testing.T) {
 }
 
 func TestAddImageReturnsIncorrectRequestBody(t *testing.T) {
-	router := setupRouter()
+	router := setupRouter(newMemoryStore())
 	w := httptest.NewRecorder()
 	r := httptest.NewRecorder()
 
@@ -236,7 +246,7 @@ func TestAddImageReturnsIncorrectRequestBody(t *testing.T) {
 }
 
 func TestAddTable(t *testing.T) {
-	router := setupRouter()
+	router := setupRouter(newMemoryStore())
 	w := httptest.NewRecorder()
 	r := httptest.NewRecorder()
 
@@ -254,11 +264,11 @@ func TestAddTable(t *testing.T) {
 	req2, _ := http.NewRequest("PUT", "/readme/17/table", bytes.NewBuffer(headerRequest))
 	router.ServeHTTP(r, req2)
 
-	require.JSONEq(t, string(`{"message":"|c1|c2|\n| --- | --- |\n|value1|value3|\n|value2|value4|\n"}`), r.Body.String())
+	requireElementResponse(t, "|c1|c2|\n| --- | --- |\n|value1|value3|\n|value2|value4|\n", r.Body.String())
 }
 
 func TestAddTableReturnsIncorrectRequestBody(t *testing.T) {
-	router := setupRouter()
+	router := setupRouter(newMemoryStore())
 	w := httptest.NewRecorder()
 	r := httptest.NewRecorder()
 
@@ -275,7 +285,7 @@ func TestAddTableReturnsIncorrectRequestBody(t *testing.T) {
 }
 
 func TestAddTableReturnsReadmeNotFound(t *testing.T) {
-	router := setupRouter()
+	router := setupRouter(newMemoryStore())
 	r := httptest.NewRecorder()
 
 	var headerRequest = []byte(`{
@@ -293,7 +303,7 @@ func TestAddTableReturnsReadmeNotFound(t *testing.T) {
 }
 
 func TestAddParagraph(t *testing.T) {
-	router := setupRouter()
+	router := setupRouter(newMemoryStore())
 	r := httptest.NewRecorder()
 	w := httptest.NewRecorder()
 
@@ -303,11 +313,11 @@ func TestAddParagraph(t *testing.T) {
 	req2, _ := http.NewRequest("PUT", "/readme/20/paragraph?paragraph=I want this to be a paragraph that will be created for the app", nil)
 	router.ServeHTTP(w, req2)
 
-	require.JSONEq(t, string(`{"message":"I want this to be a paragraph that will be created for the app\n"}`), w.Body.String())
+	requireElementResponse(t, "I want this to be a paragraph that will be created for the app\n", w.Body.String())
 }
 
 func TestAddParagraphReturnsReadmeNotFound(t *testing.T) {
-	router := setupRouter()
+	router := setupRouter(newMemoryStore())
 	r := httptest.NewRecorder()
 
 	req1, _ := http.NewRequest("PUT", "/readme/21/paragraph?paragraph=I want this to be a paragraph that will be created for the app", nil)
@@ -317,7 +327,7 @@ func TestAddParagraphReturnsReadmeNotFound(t *testing.T) {
 }
 
 func TestAddParagraphReturnsEmptyParagraph(t *testing.T) {
-	router := setupRouter()
+	router := setupRouter(newMemoryStore())
 	r := httptest.NewRecorder()
 	w := httptest.NewRecorder()
 
@@ -329,3 +339,202 @@ func TestAddParagraphReturnsEmptyParagraph(t *testing.T) {
 
 	require.JSONEq(t, string(`{"message": "paragraph cannot be empty"}`), w.Body.String())
 }
+
+func TestGetReadmeFormats(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme?name=23", nil)
+	router.ServeHTTP(w, req1)
+
+	req2, _ := http.NewRequest("PUT", "/readme/23/blockquote?blockquote=quote", nil)
+	router.ServeHTTP(w, req2)
+
+	req3, _ := http.NewRequest("PUT", "/readme/23/paragraph?paragraph=text", nil)
+	router.ServeHTTP(w, req3)
+
+	r := httptest.NewRecorder()
+	req4, _ := http.NewRequest("GET", "/readme/23", nil)
+	router.ServeHTTP(r, req4)
+	require.JSONEq(t, string(`["> quote\n", "text\n"]`), r.Body.String())
+
+	r = httptest.NewRecorder()
+	req5, _ := http.NewRequest("GET", "/readme/23?format=elements", nil)
+	router.ServeHTTP(r, req5)
+	var readme Readme
+	require.NoError(t, json.Unmarshal(r.Body.Bytes(), &readme))
+	require.Equal(t, "23", readme.NAME)
+	require.Len(t, readme.ELEMENTS, 2)
+	require.Equal(t, Element{ID: readme.ELEMENTS[0].ID, TYPE: BlockquoteElement, VALUE: "quote"}, readme.ELEMENTS[0])
+	require.Equal(t, Element{ID: readme.ELEMENTS[1].ID, TYPE: ParagraphElement, VALUE: "text"}, readme.ELEMENTS[1])
+
+	r = httptest.NewRecorder()
+	req6, _ := http.NewRequest("GET", "/readme/23?format=markdown", nil)
+	router.ServeHTTP(r, req6)
+	require.JSONEq(t, string(`{"message": "> quote\ntext\n"}`), r.Body.String())
+
+	r = httptest.NewRecorder()
+	req7, _ := http.NewRequest("GET", "/readme/23?format=html", nil)
+	router.ServeHTTP(r, req7)
+	require.Equal(t, http.StatusBadRequest, r.Code)
+}
+
+func TestListReadmes(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+
+	for _, name := range []string{"service-b", "service-a", "library", "service-c"} {
+		req, _ := http.NewRequest("POST", "/readme?name="+name, nil)
+		router.ServeHTTP(w, req)
+	}
+
+	r := httptest.NewRecorder()
+	req1, _ := http.NewRequest("GET", "/readme?prefix=service-&page=2&page_size=2", nil)
+	router.ServeHTTP(r, req1)
+
+	var response ReadmeListResponse
+	require.NoError(t, json.Unmarshal(r.Body.Bytes(), &response))
+	require.Equal(t, 3, response.TOTAL)
+	require.Equal(t, 2, response.PAGE)
+	require.Equal(t, 2, response.PAGE_SIZE)
+	require.Len(t, response.READMES, 1)
+	require.Equal(t, "service-c", response.READMES[0].NAME)
+
+	r = httptest.NewRecorder()
+	req2, _ := http.NewRequest("GET", "/readme?created_after="+time.Now().Add(time.Hour).Format(time.RFC3339), nil)
+	router.ServeHTTP(r, req2)
+
+	require.NoError(t, json.Unmarshal(r.Body.Bytes(), &response))
+	require.Equal(t, 0, response.TOTAL)
+	require.Empty(t, response.READMES)
+
+	r = httptest.NewRecorder()
+	req3, _ := http.NewRequest("GET", "/readme?created_before="+time.Now().Add(time.Hour).Format(time.RFC3339), nil)
+	router.ServeHTTP(r, req3)
+
+	require.NoError(t, json.Unmarshal(r.Body.Bytes(), &response))
+	require.Equal(t, 4, response.TOTAL)
+	require.Equal(t, "library", response.READMES[0].NAME)
+}
+
+func TestListReadmesReturnsIncorrectQuery(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+
+	for _, query := range []string{"page=0", "page_size=101", "page=one", "created_after=yesterday"} {
+		r := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/readme?"+query, nil)
+		router.ServeHTTP(r, req)
+
+		require.Equal(t, http.StatusBadRequest, r.Code, query)
+	}
+}
+
+func TestDeleteReadme(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+	r := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme?name=24", nil)
+	router.ServeHTTP(w, req1)
+
+	req2, _ := http.NewRequest("DELETE", "/readme/24", nil)
+	router.ServeHTTP(r, req2)
+
+	require.JSONEq(t, string(`{"message": "24"}`), r.Body.String())
+
+	r = httptest.NewRecorder()
+	req3, _ := http.NewRequest("GET", "/readme/24", nil)
+	router.ServeHTTP(r, req3)
+
+	require.Equal(t, http.StatusNotFound, r.Code)
+}
+
+func TestDeleteReadmeReturnsNotFoundReadme(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	r := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("DELETE", "/readme/INVALID", nil)
+	router.ServeHTTP(r, req)
+
+	require.JSONEq(t, string(`{"message": "could not find readme"}`), r.Body.String())
+}
+
+func TestDownloadReadmeFile(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme?name=my%20project", nil)
+	router.ServeHTTP(w, req1)
+
+	req2, _ := http.NewRequest("PUT", "/readme/my%20project/header", bytes.NewBufferString(`{"header_type": "LARGE_HEADING", "value": "Title"}`))
+	router.ServeHTTP(w, req2)
+
+	r := httptest.NewRecorder()
+	req3, _ := http.NewRequest("GET", "/readme/my%20project/file", nil)
+	router.ServeHTTP(r, req3)
+
+	require.Equal(t, http.StatusOK, r.Code)
+	require.Equal(t, "text/markdown; charset=utf-8", r.Header().Get("Content-Type"))
+	require.Equal(t, `attachment; filename=my-project.md`, r.Header().Get("Content-Disposition"))
+	require.Equal(t, "# Title\n", r.Body.String())
+}
+
+func TestDownloadReadmeFileReturnsReadmeNotFound(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	r := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("GET", "/readme/INVALID/file", nil)
+	router.ServeHTTP(r, req)
+
+	require.Equal(t, http.StatusNotFound, r.Code)
+	require.JSONEq(t, `{"message": "could not find readme"}`, r.Body.String())
+}
+
+func TestReadmeFileName(t *testing.T) {
+	for readmeId, expected := range map[string]string{
+		"billing":          "billing.md",
+		"my project":       "my-project.md",
+		"../../etc/passwd": "etc-passwd.md",
+		"über":             "ber.md",
+		"///":              "readme.md",
+	} {
+		require.Equal(t, expected, readmeFileName(readmeId), readmeId)
+	}
+}
+
+func TestReadmeContentDisposition(t *testing.T) {
+	for readmeId, expected := range map[string]string{
+		"my project": `attachment; filename=my-project.md`,
+		"über":       `attachment; filename=ber.md; filename*=UTF-8''%C3%BCber.md`,
+		"проект":     `attachment; filename=readme.md; filename*=UTF-8''%D0%BF%D1%80%D0%BE%D0%B5%D0%BA%D1%82.md`,
+		"日本 語":       `attachment; filename=readme.md; filename*=UTF-8''%E6%97%A5%E6%9C%AC-%E8%AA%9E.md`,
+	} {
+		require.Equal(t, expected, readmeContentDisposition(readmeId), readmeId)
+	}
+}
+
+func TestAddHeaderRejectsUnknownHeaderType(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+	r := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme?name=5", nil)
+	router.ServeHTTP(w, req1)
+
+	var headerRequest = []byte(`{
+		"header_type": "HUGE_HEADING",
+		"value": "My first header"
+	}`)
+
+	req2, _ := http.NewRequest("PUT", "/readme/5/header", bytes.NewBuffer(headerRequest))
+	router.ServeHTTP(r, req2)
+
+	require.Equal(t, http.StatusBadRequest, r.Code)
+	require.JSONEq(t, `{"message": "header_type should be one of SMALL_HEADING, MEDIUM_HEADING, LARGE_HEADING"}`, r.Body.String())
+
+	// the keys are case sensitive
+	r = httptest.NewRecorder()
+	req3, _ := http.NewRequest("PUT", "/readme/5/header", bytes.NewBufferString(`{"header_type": "small_heading", "value": "My first header"}`))
+	router.ServeHTTP(r, req3)
+	require.Equal(t, http.StatusBadRequest, r.Code)
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var ErrRevisionNotFound = errors.New("could not find revision")
+
+// Revision is the state of the elements of a readme after one change, revisions are numbered from 1.
+// Changes to the variables are recorded too but the variables themselves are not kept, undo brings them back.
+type Revision struct {
+	NUMBER     int       `json:"number"`
+	CREATED_AT time.Time `json:"created_at"`
+	AUTHOR     string    `json:"author"`
+	OPERATION  string    `json:"operation"`
+	ELEMENTS   []Element `json:"elements,omitempty"`
+}
+
+// revisionLimit is how many revisions are kept per readme. Every revision holds all of the elements and
+// the stores load them with the readme, so older ones are dropped and can no longer be read or rolled back to.
+const revisionLimit = 100
+
+// recordRevision snapshots the current elements as the next revision, dropping the oldest past revisionLimit
+func (r *Readme) recordRevision(author string, operation string, at time.Time) {
+	number := 1
+	if len(r.REVISIONS) > 0 {
+		number = r.REVISIONS[len(r.REVISIONS)-1].NUMBER + 1
+	}
+
+	r.REVISIONS = append(r.REVISIONS, Revision{
+		NUMBER:     number,
+		CREATED_AT: at,
+		AUTHOR:     author,
+		OPERATION:  operation,
+		ELEMENTS:   Readme{ELEMENTS: r.ELEMENTS}.clone().ELEMENTS,
+	})
+	if len(r.REVISIONS) > revisionLimit {
+		r.REVISIONS = append([]Revision{}, r.REVISIONS[len(r.REVISIONS)-revisionLimit:]...)
+	}
+}
+
+// revision returns the revision with the given number or ErrRevisionNotFound
+func (r *Readme) revision(number int) (Revision, error) {
+	for _, revision := range r.REVISIONS {
+		if revision.NUMBER == number {
+			return revision, nil
+		}
+	}
+
+	return Revision{}, ErrRevisionNotFound
+}
+
+// author is who made the change, taken from the X-Author header
+func author(c *gin.Context) string {
+	if author := c.GetHeader("X-Author"); author != "" {
+		return author
+	}
+
+	return "anonymous"
+}
+
+// errUncheckedSnippets stops an update that includes snippets that weren't checked to exist yet
+var errUncheckedSnippets = errors.New("the change includes snippets that weren't checked")
+
+// update applies fn to the readme and records the result as a new revision that can be undone, every
+// change to the elements or variables of a readme should go through here. The recorded revision is returned.
+//
+// Snippets the change newly includes have to exist. The stores can't be read from inside an update, so
+// when fn includes a snippet that wasn't checked the update stops, the snippets are checked and fn runs
+// again. The readme can't change in between, the snippets can't be deleted until the update is done.
+func (rc *readmeController) update(c *gin.Context, readmeId string, operation string, fn func(readme *Readme) error) (Revision, error) {
+	rc.snippets.RLock()
+	defer rc.snippets.RUnlock()
+
+	checked := make(map[string]bool)
+	for {
+		var unchecked []string
+		revision, err := rc.change(c, readmeId, operation, func(readme *Readme) error {
+			included := make(map[string]bool)
+			for _, name := range snippetNames(readme.ELEMENTS) {
+				included[name] = true
+			}
+
+			before := Readme{ELEMENTS: readme.ELEMENTS}.clone().ELEMENTS
+			variables := cloneVariables(readme.VARIABLES)
+			if err := fn(readme); err != nil {
+				return err
+			}
+			fillTaskIds(readme.ELEMENTS)
+
+			for _, name := range snippetNames(readme.ELEMENTS) {
+				if !included[name] && !checked[name] {
+					unchecked = append(unchecked, name)
+				}
+			}
+			if len(unchecked) > 0 {
+				return errUncheckedSnippets
+			}
+
+			logged := Operation{OPERATION: operation, BEFORE: before, AFTER: Readme{ELEMENTS: readme.ELEMENTS}.clone().ELEMENTS}
+			if !reflect.DeepEqual(variables, readme.VARIABLES) {
+				logged.VARIABLES = &VariablesChange{BEFORE: variables, AFTER: cloneVariables(readme.VARIABLES)}
+			}
+			readme.pushUndo(logged)
+			return nil
+		})
+		if !errors.Is(err, errUncheckedSnippets) {
+			return revision, err
+		}
+
+		if err := rc.missingSnippets(unchecked); err != nil {
+			return revision, err
+		}
+		for _, name := range unchecked {
+			checked[name] = true
+		}
+	}
+}
+
+// change is update without the undo log, used by undo and redo themselves. Watchers of the readme are
+// told about every element the change touched and the collaborative session gets the whole change,
+// both in the order the changes were made.
+func (rc *readmeController) change(c *gin.Context, readmeId string, operation string, fn func(readme *Readme) error) (Revision, error) {
+	var revision Revision
+	var before, after []Element
+	var variablesChanged bool
+
+	unlock := rc.sequence.Lock(readmeId)
+	defer unlock()
+
+	err := rc.store.Update(readmeId, func(readme *Readme) error {
+		before = Readme{ELEMENTS: readme.ELEMENTS}.clone().ELEMENTS
+		variables := cloneVariables(readme.VARIABLES)
+		if err := fn(readme); err != nil {
+			return err
+		}
+		variablesChanged = !reflect.DeepEqual(variables, readme.VARIABLES)
+
+		readme.recordRevision(author(c), operation, time.Now().UTC())
+		revision = readme.REVISIONS[len(readme.REVISIONS)-1]
+		after = revision.ELEMENTS
+		return nil
+	})
+	if err != nil {
+		return revision, err
+	}
+
+	changes := changeEvents(revision, before, after)
+	if variablesChanged {
+		changes = append(changes, ReadmeEvent{REVISION: revision.NUMBER, OPERATION: revision.OPERATION, CHANGE: "variables"})
+	}
+	rc.events.Publish(readmeId, changes...)
+	rc.sessions.broadcast(readmeId, SessionMessage{
+		TYPE:       "applied",
+		REQUEST_ID: c.GetString(sessionRequestKey),
+		AUTHOR:     revision.AUTHOR,
+		REVISION:   revision.NUMBER,
+		OPERATION:  revision.OPERATION,
+		CHANGES:    changes,
+	})
+	return revision, nil
+}
+
+// revisionParam reads the revision number from the path
+func revisionParam(c *gin.Context) (int, error) {
+	number, err := strconv.Atoi(c.Param("revision"))
+	if err != nil {
+		return 0, invalidRequestError{"revision should be a number"}
+	}
+
+	return number, nil
+}
+
+// ListRevisions godoc
+// @Summary Lists the revisions of a readme
+// @Description Every change to a readme creates a numbered revision, the elements of each revision are left out.
+// @Description Only the latest 100 revisions are kept.
+// @Accept json
+// @Produce json
+// @Param	id	path	string	true	"readme id"
+// @Success	200	{array}		Revision	"revisions oldest first"
+// @Failure 404	{object}	HttpErrorMessage	"could not find readme"
+// @Router	/readme/{id}/revisions	[get]
+func (rc *readmeController) listRevisions(c *gin.Context) {
+	readmeId := c.Param("id")
+
+	readme, err := rc.store.Get(readmeId)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	revisions := make([]Revision, len(readme.REVISIONS))
+	for i, revision := range readme.REVISIONS {
+		revision.ELEMENTS = nil
+		revisions[i] = revision
+	}
+
+	c.IndentedJSON(http.StatusOK, revisions)
+}
+
+// GetRevision godoc
+// @Summary Returns a readme as of a revision
+// @Description Takes the same format param as getting the readme
+// @Accept json
+// @Produce json
+// @Param	id	path	string	true	"readme id"
+// @Param	revision	path	int	true	"revision number"
+// @Param	format	query	string	false	"elements or markdown"
+// @Success	200	{array}		string	"list of markdown strings"
+// @Failure 404	{object}	HttpErrorMessage	"could not find readme or revision"
+// @Failure 400	{object}	HttpErrorMessage	"unknown format"
+// @Router	/readme/{id}/revisions/{revision}	[get]
+func (rc *readmeController) getRevision(c *gin.Context) {
+	readmeId := c.Param("id")
+	format := c.Query("format")
+
+	number, err := revisionParam(c)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	if !validFormat(format) {
+		c.IndentedJSON(http.StatusBadRequest, HttpErrorMessage{MESSAGE: "format should be elements or markdown"})
+		return
+	}
+
+	readme, err := rc.store.Get(readmeId)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	revision, err := readme.revision(number)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	readme.ELEMENTS = revision.ELEMENTS
+	if readme.ELEMENTS == nil {
+		readme.ELEMENTS = []Element{}
+	}
+
+	rc.respondReadme(c, readme, format)
+}
+
+// RollbackRevision godoc
+// @Summary Rolls a readme back to a revision
+// @Description Replaces the elements with the ones of the revision, the rollback is recorded as a new revision
+// @Accept json
+// @Produce json
+// @Param	id	path	string	true	"readme id"
+// @Param	revision	path	int	true	"revision number"
+// @Param	X-Author	header	string	false	"who made the change"
+// @Success	200	{object}	Revision	"the revision created by the rollback"
+// @Failure 404	{object}	HttpErrorMessage	"could not find readme or revision"
+// @Router	/readme/{id}/revisions/{revision}/rollback	[post]
+func (rc *readmeController) rollbackRevision(c *gin.Context) {
+	readmeId := c.Param("id")
+
+	number, err := revisionParam(c)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	revision, err := rc.update(c, readmeId, "rollback to "+strconv.Itoa(number), func(readme *Readme) error {
+		revision, err := readme.revision(number)
+		if err != nil {
+			return err
+		}
+
+		readme.ELEMENTS = Readme{ELEMENTS: revision.ELEMENTS}.clone().ELEMENTS
+		return nil
+	})
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, revision)
+}
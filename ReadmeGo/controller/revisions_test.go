@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListRevisions(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			router := setupRouter(store)
+			w := httptest.NewRecorder()
+			r := httptest.NewRecorder()
+
+			req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+			req1.Header.Set("X-Author", "matthew")
+			router.ServeHTTP(w, req1)
+
+			elementId := addParagraph(t, router, "1", "first")
+
+			req2, _ := http.NewRequest("DELETE", "/readme/1/element/"+elementId, nil)
+			req2.Header.Set("X-Author", "reviewer")
+			router.ServeHTTP(w, req2)
+
+			req3, _ := http.NewRequest("GET", "/readme/1/revisions", nil)
+			router.ServeHTTP(r, req3)
+
+			var revisions []Revision
+			require.NoError(t, json.Unmarshal(r.Body.Bytes(), &revisions))
+			require.Len(t, revisions, 3)
+
+			for i, expected := range []Revision{
+				{NUMBER: 1, AUTHOR: "matthew", OPERATION: "create"},
+				{NUMBER: 2, AUTHOR: "anonymous", OPERATION: "add paragraph"},
+				{NUMBER: 3, AUTHOR: "reviewer", OPERATION: "delete " + elementId},
+			} {
+				require.False(t, revisions[i].CREATED_AT.IsZero())
+				expected.CREATED_AT = revisions[i].CREATED_AT
+				require.Equal(t, expected, revisions[i])
+			}
+		})
+	}
+}
+
+func TestGetRevision(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+	r := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+	router.ServeHTTP(w, req1)
+
+	addParagraph(t, router, "1", "first")
+	addParagraph(t, router, "1", "second")
+
+	req2, _ := http.NewRequest("GET", "/readme/1/revisions/2?format=markdown", nil)
+	router.ServeHTTP(r, req2)
+
+	require.JSONEq(t, string(`{"message": "first\n"}`), r.Body.String())
+
+	r = httptest.NewRecorder()
+	req3, _ := http.NewRequest("GET", "/readme/1/revisions/1", nil)
+	router.ServeHTTP(r, req3)
+
+	require.JSONEq(t, string(`[]`), r.Body.String())
+}
+
+func TestGetRevisionReturnsRevisionNotFound(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+	router.ServeHTTP(w, req1)
+
+	for path, code := range map[string]int{"/readme/1/revisions/5": http.StatusNotFound, "/readme/1/revisions/latest": http.StatusBadRequest} {
+		r := httptest.NewRecorder()
+		req2, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(r, req2)
+
+		require.Equal(t, code, r.Code, path)
+	}
+}
+
+func TestRollbackRevision(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			router := setupRouter(store)
+			w := httptest.NewRecorder()
+			r := httptest.NewRecorder()
+
+			req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+			router.ServeHTTP(w, req1)
+
+			addParagraph(t, router, "1", "first")
+			addParagraph(t, router, "1", "second")
+			addParagraph(t, router, "1", "third")
+
+			req2, _ := http.NewRequest("POST", "/readme/1/revisions/2/rollback", nil)
+			req2.Header.Set("X-Author", "matthew")
+			router.ServeHTTP(r, req2)
+
+			var revision Revision
+			require.NoError(t, json.Unmarshal(r.Body.Bytes(), &revision))
+			require.Equal(t, 5, revision.NUMBER)
+			require.Equal(t, "matthew", revision.AUTHOR)
+			require.Equal(t, "rollback to 2", revision.OPERATION)
+			require.Equal(t, []string{"first\n"}, getRenderedReadme(t, router, "1"))
+
+			r = httptest.NewRecorder()
+			req3, _ := http.NewRequest("POST", "/readme/1/revisions/4/rollback", nil)
+			router.ServeHTTP(r, req3)
+
+			require.Equal(t, []string{"first\n", "second\n", "third\n"}, getRenderedReadme(t, router, "1"))
+		})
+	}
+}
+
+func TestRevisionsAreBounded(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			router := setupRouter(store)
+			w := httptest.NewRecorder()
+			r := httptest.NewRecorder()
+
+			req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+			router.ServeHTTP(w, req1)
+
+			for i := 0; i < revisionLimit+10; i++ {
+				addParagraph(t, router, "1", "paragraph")
+			}
+
+			req2, _ := http.NewRequest("GET", "/readme/1/revisions", nil)
+			router.ServeHTTP(r, req2)
+
+			var revisions []Revision
+			require.NoError(t, json.Unmarshal(r.Body.Bytes(), &revisions))
+			require.Len(t, revisions, revisionLimit)
+			require.Equal(t, 12, revisions[0].NUMBER)
+			require.Equal(t, revisionLimit+11, revisions[len(revisions)-1].NUMBER)
+
+			r = httptest.NewRecorder()
+			req3, _ := http.NewRequest("GET", "/readme/1/revisions/11", nil)
+			router.ServeHTTP(r, req3)
+
+			require.Equal(t, http.StatusNotFound, r.Code)
+		})
+	}
+}
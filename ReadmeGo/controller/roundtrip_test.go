@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// markdownHTML renders the markdown with the goldmark setup raw elements use
+func markdownHTML(t *testing.T, markdown string) string {
+	var rendered bytes.Buffer
+	require.NoError(t, rawMarkdown.Convert([]byte(markdown), &rendered))
+	return rendered.String()
+}
+
+// the round trip corpus, importing any of these and rendering the readme has to give back a document with the same html
+func roundTripCorpus(t *testing.T) map[string]string {
+	paths, err := filepath.Glob(filepath.Join("testdata", "roundtrip", "*.md"))
+	require.NoError(t, err)
+	require.NotEmpty(t, paths)
+
+	corpus := make(map[string]string, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		corpus[filepath.Base(path)] = string(data)
+	}
+
+	return corpus
+}
+
+func TestImportRoundTrip(t *testing.T) {
+	for name, markdown := range roundTripCorpus(t) {
+		require.Equal(t, markdownHTML(t, markdown), markdownHTML(t, renderReadme(Readme{ELEMENTS: importElements(markdown, false)})), name)
+	}
+
+	// markdown written the way the builder writes it comes back byte for byte
+	builder := roundTripCorpus(t)["builder.md"]
+	require.Equal(t, builder, renderReadme(Readme{ELEMENTS: importElements(builder, false)}))
+}
+
+func TestImportRoundTripThroughStores(t *testing.T) {
+	for storeName, store := range testStores(t) {
+		router := setupRouter(store)
+
+		for name, markdown := range roundTripCorpus(t) {
+			r := httptest.NewRecorder()
+			req1, _ := http.NewRequest("POST", "/readme/import?name="+name, bytes.NewBufferString(markdown))
+			router.ServeHTTP(r, req1)
+			require.Equal(t, http.StatusCreated, r.Code, storeName+" "+name)
+
+			r = httptest.NewRecorder()
+			req2, _ := http.NewRequest("GET", "/readme/"+name+"/file", nil)
+			router.ServeHTTP(r, req2)
+			require.Equal(t, markdownHTML(t, markdown), markdownHTML(t, r.Body.String()), storeName+" "+name)
+		}
+	}
+}
+
+func TestImportRoundTripKeepsTypedElements(t *testing.T) {
+	markdown := roundTripCorpus(t)["builder.md"]
+	elements := importElements(markdown, false)
+
+	types := make([]ElementType, len(elements))
+	for i, element := range elements {
+		types[i] = element.TYPE
+	}
+
+	require.Equal(t, []ElementType{
+		HeaderElement, ParagraphElement, RawElement, HeaderElement, BlockquoteElement, RawElement,
+		LinkElement, ImageElement, TableElement, HeaderElement, ParagraphElement,
+	}, types)
+}
+
+func TestImportRoundTripElementsCanBeEdited(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	r := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme/import?name=1", bytes.NewBufferString("# Title\n\nOld text\n"))
+	router.ServeHTTP(r, req1)
+
+	r = httptest.NewRecorder()
+	req2, _ := http.NewRequest("GET", "/readme/1?format=elements", nil)
+	router.ServeHTTP(r, req2)
+
+	var readme Readme
+	require.NoError(t, json.Unmarshal(r.Body.Bytes(), &readme))
+	require.Equal(t, ParagraphElement, readme.ELEMENTS[2].TYPE)
+
+	req3, _ := http.NewRequest("PUT", "/readme/1/element/"+readme.ELEMENTS[2].ID, bytes.NewBufferString(`{"type": "paragraph", "value": "New text"}`))
+	router.ServeHTTP(httptest.NewRecorder(), req3)
+
+	r = httptest.NewRecorder()
+	req4, _ := http.NewRequest("GET", "/readme/1/file", nil)
+	router.ServeHTTP(r, req4)
+
+	require.Equal(t, "# Title\n\nNew text\n", r.Body.String())
+}
@@ -0,0 +1,415 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var ErrSnippetNotFound = errors.New("could not find snippet")
+var ErrSnippetExists = errors.New("snippet with that name already exists")
+
+var snippetNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// SnippetStore keeps the snippets shared by readmes.
+// Every ReadmeStore is also a SnippetStore so snippets live next to the readmes using them.
+type SnippetStore interface {
+	CreateSnippet(snippet Snippet) error
+	GetSnippet(name string) (Snippet, error)
+	// UpdateSnippet replaces the stored snippet with the same name
+	UpdateSnippet(snippet Snippet) error
+	DeleteSnippet(name string) error
+	// ListSnippets returns every snippet ordered by name
+	ListSnippets() ([]Snippet, error)
+}
+
+// Snippet is a named group of elements written once and included in many readmes with a snippet element
+type Snippet struct {
+	NAME        string    `json:"name" binding:"required"`
+	DESCRIPTION string    `json:"description"`
+	ELEMENTS    []Element `json:"elements" binding:"required"`
+}
+
+type AddSnippetRequest struct {
+	SNIPPET string `json:"snippet" binding:"required"`
+}
+
+// SnippetUsage lists the readmes including a snippet
+type SnippetUsage struct {
+	NAME    string   `json:"name" binding:"required"`
+	READMES []string `json:"readmes" binding:"required"`
+}
+
+func (s Snippet) clone() Snippet {
+	s.ELEMENTS = Readme{ELEMENTS: s.ELEMENTS}.clone().ELEMENTS
+	return s
+}
+
+// validateSnippet checks a snippet before it is stored, snippets can't include other snippets
+func validateSnippet(snippet *Snippet) error {
+	if !snippetNamePattern.MatchString(snippet.NAME) {
+		return invalidRequestError{"snippet name should start with a letter or digit followed by letters, digits, dots, dashes or underscores"}
+	}
+
+	if len(snippet.ELEMENTS) == 0 {
+		return invalidRequestError{"snippet needs at least one element"}
+	}
+
+	for i := range snippet.ELEMENTS {
+		if snippet.ELEMENTS[i].TYPE == SnippetElement {
+			return invalidRequestError{"a snippet cannot include another snippet"}
+		}
+		if err := validateElement(snippet.ELEMENTS[i]); err != nil {
+			return err
+		}
+		snippet.ELEMENTS[i].ID = ""
+	}
+
+	return nil
+}
+
+// expandSnippets replaces every snippet element with the current elements of its snippet. A snippet that
+// no longer exists, brought back by undo or redo after it was deleted, keeps its placeholder comment.
+func (rc *readmeController) expandSnippets(readme Readme) (Readme, error) {
+	snippets := make(map[string][]Element)
+	missing := make(map[string]bool)
+
+	for _, element := range readme.ELEMENTS {
+		if element.TYPE != SnippetElement {
+			continue
+		}
+		if _, loaded := snippets[element.SNIPPET]; loaded {
+			continue
+		}
+
+		snippet, err := rc.store.GetSnippet(element.SNIPPET)
+		if errors.Is(err, ErrSnippetNotFound) {
+			missing[element.SNIPPET] = true
+		} else if err != nil {
+			return readme, err
+		}
+		snippets[element.SNIPPET] = snippet.ELEMENTS
+	}
+
+	if len(snippets) == 0 {
+		return readme, nil
+	}
+
+	expanded := readme.clone()
+	expanded.ELEMENTS = make([]Element, 0, len(readme.ELEMENTS))
+	for _, element := range readme.ELEMENTS {
+		if element.TYPE != SnippetElement || missing[element.SNIPPET] {
+			expanded.ELEMENTS = append(expanded.ELEMENTS, element.clone())
+			continue
+		}
+		expanded.ELEMENTS = append(expanded.ELEMENTS, Readme{ELEMENTS: snippets[element.SNIPPET]}.clone().ELEMENTS...)
+	}
+
+	return expanded, nil
+}
+
+// snippetNames returns the names of the snippets the elements include, each once
+func snippetNames(elements []Element) []string {
+	var names []string
+	included := make(map[string]bool)
+	for _, element := range elements {
+		if element.TYPE == SnippetElement && !included[element.SNIPPET] {
+			included[element.SNIPPET] = true
+			names = append(names, element.SNIPPET)
+		}
+	}
+
+	return names
+}
+
+// missingSnippets returns an interpolationError naming the snippets that don't exist. Callers hold
+// rc.snippets so none of them can be deleted until the readme including them is saved.
+func (rc *readmeController) missingSnippets(names []string) error {
+	var missing []string
+	for _, name := range names {
+		_, err := rc.store.GetSnippet(name)
+		if errors.Is(err, ErrSnippetNotFound) {
+			missing = append(missing, name)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return interpolationError{"missing snippets: " + strings.Join(missing, ", ")}
+	}
+
+	return nil
+}
+
+// render returns the readme as it is written out: snippets filled in, then variables
+func (rc *readmeController) render(readme Readme) (Readme, error) {
+	expanded, err := rc.expandSnippets(readme)
+	if err != nil {
+		return readme, err
+	}
+
+	return interpolateReadme(expanded)
+}
+
+// snippetUsage returns the readmes including each snippet, keyed by snippet name
+func (rc *readmeController) snippetUsage() (map[string][]string, error) {
+	summaries, err := rc.store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	usage := make(map[string][]string)
+	for _, summary := range summaries {
+		readme, err := rc.store.Get(summary.NAME)
+		// deleted since the readmes were listed
+		if errors.Is(err, ErrReadmeNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		included := make(map[string]bool)
+		for _, element := range readme.ELEMENTS {
+			if element.TYPE == SnippetElement && !included[element.SNIPPET] {
+				included[element.SNIPPET] = true
+				usage[element.SNIPPET] = append(usage[element.SNIPPET], readme.NAME)
+			}
+		}
+	}
+
+	return usage, nil
+}
+
+// respondSnippetError is respondStoreError for snippets
+func respondSnippetError(c *gin.Context, err error) {
+	if errors.Is(err, ErrSnippetNotFound) {
+		c.IndentedJSON(http.StatusNotFound, HttpErrorMessage{MESSAGE: "could not find snippet"})
+		return
+	}
+
+	if errors.Is(err, ErrSnippetExists) {
+		c.IndentedJSON(http.StatusConflict, HttpErrorMessage{MESSAGE: "Snippet with that name already exists"})
+		return
+	}
+
+	respondStoreError(c, err)
+}
+
+// ListSnippets godoc
+// @Summary Lists the snippets
+// @Accept json
+// @Produce json
+// @Success	200	{array}		Snippet	"every snippet ordered by name"
+// @Router	/snippet	[get]
+func (rc *readmeController) listSnippets(c *gin.Context) {
+	snippets, err := rc.store.ListSnippets()
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, snippets)
+}
+
+// GetSnippet godoc
+// @Summary Returns a snippet
+// @Accept json
+// @Produce json
+// @Param	name	path	string	true	"snippet name"
+// @Success	200	{object}	Snippet	"the snippet"
+// @Failure 404	{object}	HttpErrorMessage	"could not find snippet"
+// @Router	/snippet/{name}	[get]
+func (rc *readmeController) getSnippet(c *gin.Context) {
+	snippet, err := rc.store.GetSnippet(c.Param("name"))
+	if err != nil {
+		respondSnippetError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, snippet)
+}
+
+// CreateSnippet godoc
+// @Summary Creates a snippet
+// @Description Stores elements to be included in readmes with PUT /readme/{id}/snippet
+// @Accept json
+// @Produce json
+// @Param	snippet	body	Snippet	true	"the snippet"
+// @Success	201	{object}	HttpMessage	"returns the snippet name"
+// @Failure 400	{object}	HttpErrorMessage	"incorrect request body"
+// @Failure 409	{object}	HttpErrorMessage	"Snippet already exists"
+// @Router	/snippet	[post]
+func (rc *readmeController) createSnippet(c *gin.Context) {
+	var snippet Snippet
+
+	if err := c.BindJSON(&snippet); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, HttpErrorMessage{MESSAGE: "incorrect request body, should be Snippet body"})
+		return
+	}
+
+	if err := validateSnippet(&snippet); err != nil {
+		respondSnippetError(c, err)
+		return
+	}
+
+	if err := rc.store.CreateSnippet(snippet); err != nil {
+		respondSnippetError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusCreated, HttpMessage{MESSAGE: snippet.NAME})
+}
+
+// UpdateSnippet godoc
+// @Summary Replaces a snippet
+// @Description Every readme including the snippet renders the new elements from now on
+// @Accept json
+// @Produce json
+// @Param	name	path	string	true	"snippet name"
+// @Param	snippet	body	Snippet	true	"the new snippet, its name is taken from the path"
+// @Success	200	{object}	HttpMessage	"returns the snippet name"
+// @Failure 400	{object}	HttpErrorMessage	"incorrect request body"
+// @Failure 404	{object}	HttpErrorMessage	"could not find snippet"
+// @Router	/snippet/{name}	[put]
+func (rc *readmeController) updateSnippet(c *gin.Context) {
+	name := c.Param("name")
+	var snippet Snippet
+
+	// the name can be left out of the body
+	snippet.NAME = name
+	if err := c.BindJSON(&snippet); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, HttpErrorMessage{MESSAGE: "incorrect request body, should be Snippet body"})
+		return
+	}
+	snippet.NAME = name
+
+	if err := validateSnippet(&snippet); err != nil {
+		respondSnippetError(c, err)
+		return
+	}
+
+	if err := rc.store.UpdateSnippet(snippet); err != nil {
+		respondSnippetError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, HttpMessage{MESSAGE: name})
+}
+
+// DeleteSnippet godoc
+// @Summary Deletes a snippet
+// @Description A snippet still included in readmes can't be deleted, the readmes are listed in the response.
+// @Description Undo or redo can include it again afterwards, it then renders as a placeholder comment.
+// @Accept json
+// @Produce json
+// @Param	name	path	string	true	"snippet name"
+// @Success	200	{object}	HttpMessage	"returns the deleted snippet name"
+// @Failure 404	{object}	HttpErrorMessage	"could not find snippet"
+// @Failure 409	{object}	SnippetUsage	"readmes still including the snippet"
+// @Router	/snippet/{name}	[delete]
+func (rc *readmeController) deleteSnippet(c *gin.Context) {
+	name := c.Param("name")
+
+	// no readme can include the snippet between checking it is unused and deleting it
+	rc.snippets.Lock()
+	defer rc.snippets.Unlock()
+
+	if _, err := rc.store.GetSnippet(name); err != nil {
+		respondSnippetError(c, err)
+		return
+	}
+
+	usage, err := rc.snippetUsage()
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	if len(usage[name]) > 0 {
+		c.IndentedJSON(http.StatusConflict, SnippetUsage{NAME: name, READMES: usage[name]})
+		return
+	}
+
+	if err := rc.store.DeleteSnippet(name); err != nil {
+		respondSnippetError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, HttpMessage{MESSAGE: name})
+}
+
+// SnippetUsageReport godoc
+// @Summary Reports which readmes use each snippet
+// @Description Every snippet with the readmes including it, ordered by snippet name. Snippets included in readmes that
+// @Description don't exist are listed too, so the readmes left with a placeholder can be found.
+// @Accept json
+// @Produce json
+// @Success	200	{array}		SnippetUsage	"the readmes using each snippet"
+// @Router	/snippet/usage	[get]
+func (rc *readmeController) snippetUsageReport(c *gin.Context) {
+	snippets, err := rc.store.ListSnippets()
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	usage, err := rc.snippetUsage()
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	for _, snippet := range snippets {
+		if _, ok := usage[snippet.NAME]; !ok {
+			usage[snippet.NAME] = []string{}
+		}
+	}
+
+	report := make([]SnippetUsage, 0, len(usage))
+	for name, readmes := range usage {
+		report = append(report, SnippetUsage{NAME: name, READMES: readmes})
+	}
+
+	sort.Slice(report, func(i, j int) bool { return report[i].NAME < report[j].NAME })
+	c.IndentedJSON(http.StatusOK, report)
+}
+
+// AddSnippet godoc
+// @Summary Includes a snippet
+// @Description Adds an element standing in for the snippet, the readme renders whatever the snippet holds at the time
+// @Accept json
+// @Produce json
+// @Param	id	path	string	true	"readme id"
+// @Param	addSnippet	body	AddSnippetRequest	true	"the snippet to include"
+// @Param	index	query	int	false	"insert the element at this index"
+// @Param	before	query	string	false	"insert the element before this element id"
+// @Param	after	query	string	false	"insert the element after this element id"
+// @Param	X-Author	header	string	false	"who made the change"
+// @Success	200	{object}	ElementResponse	"returns the element id and markdown string"
+// @Failure 404	{object}	HttpErrorMessage	"could not find readme or snippet"
+// @Failure 400	{object}	HttpErrorMessage	"incorrect request body"
+// @Router	/readme/{id}/snippet	[put]
+func (rc *readmeController) addSnippet(c *gin.Context) {
+	readmeId := c.Param("id")
+	var addSnippetRequest AddSnippetRequest
+
+	if err := c.BindJSON(&addSnippetRequest); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, HttpErrorMessage{MESSAGE: "incorrect request body, should be AddSnippetRequest body"})
+		return
+	}
+
+	// checked again by the update, this only keeps the not found response
+	if _, err := rc.store.GetSnippet(addSnippetRequest.SNIPPET); err != nil {
+		respondSnippetError(c, err)
+		return
+	}
+
+	rc.addElement(c, readmeId, Element{TYPE: SnippetElement, SNIPPET: addSnippetRequest.SNIPPET})
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func createSnippet(t *testing.T, router *gin.Engine, snippet string) {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/snippet", bytes.NewBufferString(snippet))
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+}
+
+func includeSnippet(t *testing.T, router *gin.Engine, readmeId string, snippet string) {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/readme/"+readmeId+"/snippet", bytes.NewBufferString(`{"snippet": "`+snippet+`"}`))
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
+
+func TestSnippetsAreRenderedFromTheLatestVersion(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			router := setupRouter(store)
+			createSnippet(t, router, `{"name": "run-tests", "elements": [
+				{"type": "header", "level": 2, "value": "Testing"},
+				{"type": "raw", "value": "    go test ./...\n"}
+			]}`)
+
+			for _, readmeId := range []string{"1", "2"} {
+				w := httptest.NewRecorder()
+				req, _ := http.NewRequest("POST", "/readme?name="+readmeId, nil)
+				router.ServeHTTP(w, req)
+
+				addParagraph(t, router, readmeId, "readme "+readmeId)
+				includeSnippet(t, router, readmeId, "run-tests")
+			}
+
+			require.Equal(t, []string{"readme 1\n", "## Testing\n", "    go test ./...\n"}, getRenderedReadme(t, router, "1"))
+
+			r := httptest.NewRecorder()
+			req1, _ := http.NewRequest("PUT", "/snippet/run-tests", bytes.NewBufferString(`{"elements": [{"type": "header", "level": 2, "value": "Running the tests"}]}`))
+			router.ServeHTTP(r, req1)
+			require.Equal(t, http.StatusOK, r.Code)
+
+			// every readme including the snippet picks up the change
+			require.Equal(t, []string{"readme 1\n", "## Running the tests\n"}, getRenderedReadme(t, router, "1"))
+			require.Equal(t, []string{"readme 2\n", "## Running the tests\n"}, getRenderedReadme(t, router, "2"))
+
+			r = httptest.NewRecorder()
+			req2, _ := http.NewRequest("GET", "/readme/2/file", nil)
+			router.ServeHTTP(r, req2)
+			require.Equal(t, "readme 2\n## Running the tests\n", r.Body.String())
+
+			// the elements keep the reference
+			readme, err := store.Get("1")
+			require.NoError(t, err)
+			require.Equal(t, SnippetElement, readme.ELEMENTS[1].TYPE)
+			require.Equal(t, "run-tests", readme.ELEMENTS[1].SNIPPET)
+		})
+	}
+}
+
+func TestSnippetUsageReport(t *testing.T) {
+	store := newMemoryStore()
+	router := setupRouter(store)
+	createSnippet(t, router, `{"name": "license", "elements": [{"type": "paragraph", "value": "Apache 2.0"}]}`)
+	createSnippet(t, router, `{"name": "unused", "elements": [{"type": "paragraph", "value": "nobody"}]}`)
+
+	for _, readmeId := range []string{"b", "a", "c"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/readme?name="+readmeId, nil)
+		router.ServeHTTP(w, req)
+	}
+	includeSnippet(t, router, "b", "license")
+	includeSnippet(t, router, "b", "license")
+	includeSnippet(t, router, "a", "license")
+
+	// a reference to a snippet that is gone still shows up in the report
+	require.NoError(t, store.Update("c", func(readme *Readme) error {
+		readme.ELEMENTS = append(readme.ELEMENTS, Element{ID: "x", TYPE: SnippetElement, SNIPPET: "deleted"})
+		return nil
+	}))
+
+	w := httptest.NewRecorder()
+	req1, _ := http.NewRequest("GET", "/snippet/usage", nil)
+	router.ServeHTTP(w, req1)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var report []SnippetUsage
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	require.Equal(t, []SnippetUsage{
+		{NAME: "deleted", READMES: []string{"c"}},
+		{NAME: "license", READMES: []string{"a", "b"}},
+		{NAME: "unused", READMES: []string{}},
+	}, report)
+
+	require.Equal(t, []string{"<!-- snippet deleted -->\n"}, getRenderedReadme(t, router, "c"))
+}
+
+func TestDeleteSnippetRefusesWhileIncluded(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	createSnippet(t, router, `{"name": "license", "elements": [{"type": "paragraph", "value": "Apache 2.0"}]}`)
+
+	w := httptest.NewRecorder()
+	req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+	router.ServeHTTP(w, req1)
+	includeSnippet(t, router, "1", "license")
+
+	r := httptest.NewRecorder()
+	req2, _ := http.NewRequest("DELETE", "/snippet/license", nil)
+	router.ServeHTTP(r, req2)
+
+	require.Equal(t, http.StatusConflict, r.Code)
+
+	var usage SnippetUsage
+	require.NoError(t, json.Unmarshal(r.Body.Bytes(), &usage))
+	require.Equal(t, SnippetUsage{NAME: "license", READMES: []string{"1"}}, usage)
+
+	r = httptest.NewRecorder()
+	req3, _ := http.NewRequest("DELETE", "/readme/1", nil)
+	router.ServeHTTP(r, req3)
+
+	r = httptest.NewRecorder()
+	req4, _ := http.NewRequest("DELETE", "/snippet/license", nil)
+	router.ServeHTTP(r, req4)
+	require.Equal(t, http.StatusOK, r.Code)
+
+	r = httptest.NewRecorder()
+	req5, _ := http.NewRequest("GET", "/snippet/license", nil)
+	router.ServeHTTP(r, req5)
+	require.Equal(t, http.StatusNotFound, r.Code)
+}
+
+func TestSnippetsFillInReadmeVariables(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	createSnippet(t, router, `{"name": "install", "elements": [{"type": "raw", "value": "    go get {{.ModulePath}}\n"}]}`)
+
+	w := httptest.NewRecorder()
+	req1, _ := http.NewRequest("POST", "/readme/spec", bytes.NewBufferString(`{"name": "1", "variables": {"ModulePath": "example.com/readmego"}, "elements": [{"type": "snippet", "snippet": "install"}]}`))
+	router.ServeHTTP(w, req1)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	require.Equal(t, []string{"    go get example.com/readmego\n"}, getRenderedReadme(t, router, "1"))
+}
+
+func TestCreateSnippetReturnsInvalidSnippet(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	createSnippet(t, router, `{"name": "license", "elements": [{"type": "paragraph", "value": "Apache 2.0"}]}`)
+
+	for _, snippet := range []string{
+		`{"name": "nested", "elements": [{"type": "snippet", "snippet": "license"}]}`,
+		`{"name": "empty", "elements": []}`,
+		`{"name": "has spaces", "elements": [{"type": "paragraph", "value": "x"}]}`,
+		`{"name": "bad", "elements": [{"type": "header", "level": 0, "value": "x"}]}`,
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/snippet", bytes.NewBufferString(snippet))
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusBadRequest, w.Code, snippet)
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/snippet", bytes.NewBufferString(`{"name": "license", "elements": [{"type": "paragraph", "value": "MIT"}]}`))
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestAddSnippetReturnsSnippetNotFound(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+	router.ServeHTTP(w, req1)
+
+	r := httptest.NewRecorder()
+	req2, _ := http.NewRequest("PUT", "/readme/1/snippet", bytes.NewBufferString(`{"snippet": "missing"}`))
+	router.ServeHTTP(r, req2)
+
+	require.Equal(t, http.StatusNotFound, r.Code)
+	require.Equal(t, "{\n    \"message\": \"could not find snippet\"\n}", r.Body.String())
+}
+
+func TestUndoingBackADeletedSnippetRendersItsPlaceholder(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	createSnippet(t, router, `{"name": "license", "elements": [{"type": "paragraph", "value": "Apache 2.0"}]}`)
+
+	w := httptest.NewRecorder()
+	req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+	router.ServeHTTP(w, req1)
+	includeSnippet(t, router, "1", "license")
+
+	for _, request := range []struct{ method, path string }{
+		{"POST", "/readme/1/undo"},
+		{"DELETE", "/snippet/license"},
+		{"POST", "/readme/1/redo"},
+	} {
+		r := httptest.NewRecorder()
+		req, _ := http.NewRequest(request.method, request.path, nil)
+		router.ServeHTTP(r, req)
+		require.Equal(t, http.StatusOK, r.Code, request.path)
+	}
+
+	require.Equal(t, []string{"<!-- snippet license -->\n"}, getRenderedReadme(t, router, "1"))
+
+	// a new readme can't start out with the reference
+	r := httptest.NewRecorder()
+	req2, _ := http.NewRequest("POST", "/readme/spec", bytes.NewBufferString(`{"name": "2", "elements": [{"type": "snippet", "snippet": "license"}]}`))
+	router.ServeHTTP(r, req2)
+	require.Equal(t, http.StatusUnprocessableEntity, r.Code)
+	require.Equal(t, "{\n    \"message\": \"missing snippets: license\"\n}", r.Body.String())
+}
+
+func TestEveryChangeChecksTheSnippetsItIncludes(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			router := setupRouter(store)
+			createSnippet(t, router, `{"name": "license", "elements": [{"type": "paragraph", "value": "Apache 2.0"}]}`)
+
+			w := httptest.NewRecorder()
+			req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+			router.ServeHTTP(w, req1)
+			first := addParagraph(t, router, "1", "first")
+
+			r := httptest.NewRecorder()
+			req2, _ := http.NewRequest("PUT", "/readme/1/element/"+first, bytes.NewBufferString(`{"type": "snippet", "snippet": "ghost"}`))
+			router.ServeHTTP(r, req2)
+			require.Equal(t, http.StatusUnprocessableEntity, r.Code)
+			require.JSONEq(t, `{"message": "missing snippets: ghost"}`, r.Body.String())
+
+			r = httptest.NewRecorder()
+			req3, _ := http.NewRequest("PUT", "/readme/1/element/"+first, bytes.NewBufferString(`{"type": "snippet", "snippet": "license"}`))
+			router.ServeHTTP(r, req3)
+			require.Equal(t, http.StatusOK, r.Code, r.Body.String())
+			require.Equal(t, []string{"Apache 2.0\n"}, getRenderedReadme(t, router, "1"))
+
+			r = httptest.NewRecorder()
+			req4, _ := http.NewRequest("POST", "/template", bytes.NewBufferString(`{"name": "haunted", "elements": [{"type": "snippet", "snippet": "ghost"}]}`))
+			router.ServeHTTP(r, req4)
+			require.Equal(t, http.StatusUnprocessableEntity, r.Code)
+		})
+	}
+}
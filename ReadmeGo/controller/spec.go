@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"gopkg.in/yaml.v3"
+)
+
+// maxSpecSize is the largest spec that can be posted, in bytes
+const maxSpecSize = 1 << 20
+
+//go:embed readme-spec.schema.json
+var readmeSpecSchemaSource []byte
+
+var readmeSpecSchema = func() *jsonschema.Schema {
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource("readme-spec.schema.json", bytes.NewReader(readmeSpecSchemaSource)); err != nil {
+		panic(err)
+	}
+	return compiler.MustCompile("readme-spec.schema.json")
+}()
+
+// ReadmeSpec describes a whole readme, it is validated against readme-spec.schema.json
+type ReadmeSpec struct {
+	NAME      string            `json:"name"`
+	ELEMENTS  []Element         `json:"elements" binding:"required"`
+	VARIABLES map[string]string `json:"variables"`
+}
+
+type SpecResponse struct {
+	ID       string `json:"id" binding:"required"`
+	MARKDOWN string `json:"markdown" binding:"required"`
+}
+
+// schemaErrors lists the innermost reasons the spec didn't match the schema
+func schemaErrors(err *jsonschema.ValidationError) []string {
+	if len(err.Causes) == 0 {
+		location := err.InstanceLocation
+		if location == "" {
+			location = "/"
+		}
+		return []string{location + ": " + err.Message}
+	}
+
+	var messages []string
+	for _, cause := range err.Causes {
+		messages = append(messages, schemaErrors(cause)...)
+	}
+	return messages
+}
+
+// parseSpec reads a JSON or YAML spec, JSON is valid YAML so both are read as YAML, and checks it against the schema
+func parseSpec(data []byte) (ReadmeSpec, error) {
+	var document interface{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return ReadmeSpec{}, invalidRequestError{"spec is not valid JSON or YAML: " + err.Error()}
+	}
+
+	// the schema validator works on values as encoding/json decodes them
+	encoded, err := json.Marshal(document)
+	if err != nil {
+		return ReadmeSpec{}, invalidRequestError{"spec is not valid JSON or YAML: " + err.Error()}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var instance interface{}
+	if err := decoder.Decode(&instance); err != nil {
+		return ReadmeSpec{}, err
+	}
+
+	if err := readmeSpecSchema.Validate(instance); err != nil {
+		var validation *jsonschema.ValidationError
+		if errors.As(err, &validation) {
+			return ReadmeSpec{}, invalidRequestError{"spec does not match the schema: " + strings.Join(schemaErrors(validation), "; ")}
+		}
+		return ReadmeSpec{}, err
+	}
+
+	var spec ReadmeSpec
+	if err := json.Unmarshal(encoded, &spec); err != nil {
+		return ReadmeSpec{}, invalidRequestError{"spec does not match the schema: " + err.Error()}
+	}
+
+	// the schema can't know everything the builder checks
+	for i := range spec.ELEMENTS {
+		if err := validateElement(spec.ELEMENTS[i]); err != nil {
+			return ReadmeSpec{}, err
+		}
+		spec.ELEMENTS[i].ID = uuid.NewString()
+	}
+
+	if spec.ELEMENTS == nil {
+		spec.ELEMENTS = []Element{}
+	}
+
+	return spec, nil
+}
+
+// CreateReadmeFromSpec godoc
+// @Summary Creates a readme from a spec
+// @Description Creates a whole readme in one call from a JSON or YAML document listing its elements in order.
+// @Description The document has to match the JSON Schema served at /readme/spec/schema.
+// @Accept json
+// @Accept application/yaml
+// @Produce json
+// @Param	spec	body	ReadmeSpec	true	"the readme spec"
+// @Param	X-Author	header	string	false	"who made the change"
+// @Success	201		{object}	SpecResponse	"the readme id and its markdown"
+// @Failure	400		{object}	HttpErrorMessage	"spec does not match the schema"
+// @Failure	409		{object}	HttpErrorMessage	"Readme already exists"
+// @Failure	422		{object}	HttpErrorMessage	"elements use variables the spec doesn't set or snippets that don't exist"
+// @Router	/readme/spec	[post]
+func (rc *readmeController) createReadmeFromSpec(c *gin.Context) {
+	data, err := io.ReadAll(io.LimitReader(c.Request.Body, maxSpecSize+1))
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, HttpErrorMessage{MESSAGE: "could not read the spec"})
+		return
+	}
+
+	if len(data) > maxSpecSize {
+		c.IndentedJSON(http.StatusRequestEntityTooLarge, HttpErrorMessage{MESSAGE: "spec should be at most 1MB"})
+		return
+	}
+
+	spec, err := parseSpec(data)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	if spec.NAME == "" {
+		spec.NAME = uuid.NewString()
+	}
+
+	// a spec has to render, like the readme it creates
+	readme := Readme{NAME: spec.NAME, ELEMENTS: spec.ELEMENTS, VARIABLES: spec.VARIABLES}
+	rendered, err := rc.render(readme)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	if err := rc.create(c, readme, "create from spec"); err != nil {
+		respondCreateError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusCreated, SpecResponse{ID: spec.NAME, MARKDOWN: renderReadme(rendered)})
+}
+
+// GetSpecSchema godoc
+// @Summary Returns the readme spec JSON Schema
+// @Description The JSON Schema every spec posted to /readme/spec is checked against
+// @Produce json
+// @Success	200		{object}	object	"the JSON Schema"
+// @Router	/readme/spec/schema	[get]
+func (rc *readmeController) getSpecSchema(c *gin.Context) {
+	c.Data(http.StatusOK, "application/schema+json", readmeSpecSchemaSource)
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateReadmeFromSpec(t *testing.T) {
+	for name, spec := range map[string]string{
+		"json": `{
+			"name": "billing",
+			"elements": [
+				{"type": "header", "level": 1, "value": "Billing"},
+				{"type": "paragraph", "value": "Handles invoices"},
+				{"type": "code", "code_language": "go", "value": "go run ."},
+				{"type": "table", "column_names": ["Name"], "column_values": {"Name": ["api"]}}
+			]
+		}`,
+		"yaml": `
+name: billing
+elements:
+  - type: header
+    level: 1
+    value: Billing
+  - type: paragraph
+    value: Handles invoices
+  - type: code
+    code_language: go
+    value: go run .
+  - type: table
+    column_names: [Name]
+    column_values:
+      Name: [api]
+`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			router := setupRouter(newMemoryStore())
+			r := httptest.NewRecorder()
+
+			req1, _ := http.NewRequest("POST", "/readme/spec", bytes.NewBufferString(spec))
+			router.ServeHTTP(r, req1)
+
+			require.Equal(t, http.StatusCreated, r.Code)
+
+			var response SpecResponse
+			require.NoError(t, json.Unmarshal(r.Body.Bytes(), &response))
+			markdown := "# Billing\nHandles invoices\n```go\ngo run .\n```\n|Name|\n| --- |\n|api|\n"
+			require.Equal(t, SpecResponse{ID: "billing", MARKDOWN: markdown}, response)
+			require.Equal(t, []string{"# Billing\n", "Handles invoices\n", "```go\ngo run .\n```\n", "|Name|\n| --- |\n|api|\n"}, getRenderedReadme(t, router, "billing"))
+		})
+	}
+}
+
+func TestCreateReadmeFromSpecWithVariables(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+
+	spec := `
+name: billing
+variables:
+  ProjectName: Billing
+elements:
+  - type: header
+    level: 1
+    value: "{{.ProjectName}}"
+`
+	r := httptest.NewRecorder()
+	req1, _ := http.NewRequest("POST", "/readme/spec", bytes.NewBufferString(spec))
+	router.ServeHTTP(r, req1)
+
+	require.Equal(t, http.StatusCreated, r.Code)
+	require.Equal(t, []string{"# Billing\n"}, getRenderedReadme(t, router, "billing"))
+
+	// a spec using a variable it doesn't set is refused
+	w := httptest.NewRecorder()
+	req2, _ := http.NewRequest("POST", "/readme/spec", bytes.NewBufferString(`{"name": "other", "variables": {}, "elements": [{"type": "paragraph", "value": "{{.Owner}}"}]}`))
+	router.ServeHTTP(w, req2)
+
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	require.Contains(t, w.Body.String(), "undefined variables: Owner")
+}
+
+func TestCreateReadmeFromSpecValidatesAgainstSchema(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+
+	for spec, message := range map[string]string{
+		`{}`: `spec does not match the schema: /: missing properties: 'elements'`,
+		`{"elements": [{"type": "header", "value": "x"}]}`:                         `spec does not match the schema: /elements/0: missing properties: 'level'`,
+		`{"elements": [{"type": "code", "code_language": "cobol", "value": "x"}]}`: `spec does not match the schema: /elements/0/code_language: value must be one of "go", "java", "json"`,
+		`{"elements": [{"type": "paragraph", "value": "x", "id": "1"}]}`:           `spec does not match the schema: /elements/0: additionalProperties 'id' not allowed`,
+		`{"elements": [{"type": "video"}]}`:                                        `spec does not match the schema: /elements/0/type: value must be one of "header", "paragraph", "code", "blockquote", "link", "image", "table", "list", "tasklist", "raw", "snippet"`,
+	} {
+		r := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/readme/spec", bytes.NewBufferString(spec))
+		router.ServeHTTP(r, req)
+
+		require.Equal(t, http.StatusBadRequest, r.Code, spec)
+		require.JSONEq(t, `{"message": `+string(mustJSON(t, message))+`}`, r.Body.String(), spec)
+	}
+
+	r := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/readme/spec", bytes.NewBufferString("elements: [\n"))
+	router.ServeHTTP(r, req)
+	require.Equal(t, http.StatusBadRequest, r.Code)
+}
+
+func TestCreateReadmeFromSpecReturnsConflict(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+	r := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+	router.ServeHTTP(w, req1)
+
+	req2, _ := http.NewRequest("POST", "/readme/spec", bytes.NewBufferString(`{"name": "1", "elements": []}`))
+	router.ServeHTTP(r, req2)
+
+	require.Equal(t, http.StatusConflict, r.Code)
+}
+
+func TestGetSpecSchema(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	r := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("GET", "/readme/spec/schema", nil)
+	router.ServeHTTP(r, req)
+
+	require.Equal(t, http.StatusOK, r.Code)
+	require.Equal(t, "application/schema+json", r.Header().Get("Content-Type"))
+
+	var schema struct {
+		DEFINITIONS struct {
+			ELEMENT struct {
+				PROPERTIES struct {
+					CODE_LANGUAGE struct {
+						ENUM []string `json:"enum"`
+					} `json:"code_language"`
+				} `json:"properties"`
+			} `json:"element"`
+		} `json:"definitions"`
+	}
+	require.NoError(t, json.Unmarshal(r.Body.Bytes(), &schema))
+
+	// the schema has to offer the same code languages as the code endpoint
+	var languages []string
+	for language := range codeLanguageMap {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	require.Equal(t, languages, schema.DEFINITIONS.ELEMENT.PROPERTIES.CODE_LANGUAGE.ENUM)
+}
+
+func mustJSON(t *testing.T, value interface{}) []byte {
+	data, err := json.Marshal(value)
+	require.NoError(t, err)
+	return data
+}
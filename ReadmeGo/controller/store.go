@@ -0,0 +1,361 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+var ErrReadmeNotFound = errors.New("could not find readme")
+var ErrReadmeExists = errors.New("readme with that id already exists")
+
+// ReadmeStore is the persistence layer used by the readme handlers.
+// Implementations must be safe for concurrent use, writes to one readme are applied one at a time.
+type ReadmeStore interface {
+	TemplateStore
+	SnippetStore
+	Create(readme Readme) error
+	Get(readmeId string) (Readme, error)
+	Append(readmeId string, element Element) error
+	// Update runs fn while holding the readme so no other write can interleave with it.
+	// Changes fn makes to the readme are saved, an error leaves it untouched.
+	Update(readmeId string, fn func(readme *Readme) error) error
+	Replace(readmeId string, elements []Element) error
+	Delete(readmeId string) error
+	// List returns every readme ordered by name
+	List() ([]ReadmeSummary, error)
+}
+
+// newReadmeStore returns the store selected at startup
+func newReadmeStore(kind string, path string) (ReadmeStore, error) {
+	switch kind {
+	case "memory":
+		return newMemoryStore(), nil
+	case "file":
+		return newFileStore(path)
+	case "sqlite":
+		return newSqliteStore(filepath.Join(path, "readme.db"))
+	}
+
+	return nil, fmt.Errorf("unknown store %q, should be memory, file or sqlite", kind)
+}
+
+// legacyReadme converts the rendered markdown strings readmes used to be stored as into raw elements
+func legacyReadme(readmeId string, lines []string) Readme {
+	readme := Readme{NAME: readmeId, ELEMENTS: []Element{}}
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		readme.ELEMENTS = append(readme.ELEMENTS, Element{TYPE: RawElement, VALUE: line})
+	}
+
+	return readme
+}
+
+// ensureElementIds gives an id to every element stored before elements had ids and reports if any were missing
+func ensureElementIds(readme *Readme) bool {
+	changed := false
+	for i := range readme.ELEMENTS {
+		if readme.ELEMENTS[i].ID == "" {
+			readme.ELEMENTS[i].ID = uuid.NewString()
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+// keyedMutex serializes work per key, locks are dropped once nobody is waiting on them
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	mu      sync.Mutex
+	waiting int
+}
+
+func newKeyedMutex() *keyedMutex {
+	return &keyedMutex{locks: make(map[string]*keyedLock)}
+}
+
+// Lock blocks until key is free and returns the func that releases it
+func (k *keyedMutex) Lock(key string) func() {
+	k.mu.Lock()
+	lock, ok := k.locks[key]
+	if !ok {
+		lock = &keyedLock{}
+		k.locks[key] = lock
+	}
+	lock.waiting++
+	k.mu.Unlock()
+
+	lock.mu.Lock()
+
+	return func() {
+		lock.mu.Unlock()
+
+		k.mu.Lock()
+		lock.waiting--
+		if lock.waiting == 0 {
+			delete(k.locks, key)
+		}
+		k.mu.Unlock()
+	}
+}
+
+// memoryStore keeps readmes in a map, everything is lost on restart
+type memoryStore struct {
+	mu        sync.RWMutex
+	readmes   map[string]*memoryReadme
+	templates map[string]Template
+	snippets  map[string]Snippet
+}
+
+// memoryReadme has its own lock so writes to different readmes don't wait on each other
+type memoryReadme struct {
+	mu      sync.Mutex
+	readme  Readme
+	deleted bool
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{readmes: make(map[string]*memoryReadme), templates: make(map[string]Template), snippets: make(map[string]Snippet)}
+}
+
+// readme returns the locked readme, the caller must unlock it
+func (s *memoryStore) readme(readmeId string) (*memoryReadme, error) {
+	s.mu.RLock()
+	stored, ok := s.readmes[readmeId]
+	s.mu.RUnlock()
+
+	if !ok {
+		return nil, ErrReadmeNotFound
+	}
+
+	stored.mu.Lock()
+	// the readme may have been deleted while we waited for the lock
+	if stored.deleted {
+		stored.mu.Unlock()
+		return nil, ErrReadmeNotFound
+	}
+
+	return stored, nil
+}
+
+func (s *memoryStore) Create(readme Readme) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.readmes[readme.NAME]; ok {
+		return ErrReadmeExists
+	}
+
+	s.readmes[readme.NAME] = &memoryReadme{readme: readme.clone()}
+	return nil
+}
+
+func (s *memoryStore) Get(readmeId string) (Readme, error) {
+	stored, err := s.readme(readmeId)
+	if err != nil {
+		return Readme{}, err
+	}
+	defer stored.mu.Unlock()
+
+	return stored.readme.clone(), nil
+}
+
+func (s *memoryStore) Update(readmeId string, fn func(readme *Readme) error) error {
+	stored, err := s.readme(readmeId)
+	if err != nil {
+		return err
+	}
+	defer stored.mu.Unlock()
+
+	readme := stored.readme.clone()
+	if err := fn(&readme); err != nil {
+		return err
+	}
+
+	stored.readme = readme
+	return nil
+}
+
+func (s *memoryStore) Append(readmeId string, element Element) error {
+	return s.Update(readmeId, func(readme *Readme) error {
+		readme.ELEMENTS = append(readme.ELEMENTS, element.clone())
+		return nil
+	})
+}
+
+func (s *memoryStore) Replace(readmeId string, elements []Element) error {
+	return s.Update(readmeId, func(readme *Readme) error {
+		readme.ELEMENTS = Readme{ELEMENTS: elements}.clone().ELEMENTS
+		return nil
+	})
+}
+
+func (s *memoryStore) Delete(readmeId string) error {
+	s.mu.Lock()
+	stored, ok := s.readmes[readmeId]
+	if !ok {
+		s.mu.Unlock()
+		return ErrReadmeNotFound
+	}
+	delete(s.readmes, readmeId)
+	s.mu.Unlock()
+
+	stored.mu.Lock()
+	stored.deleted = true
+	stored.mu.Unlock()
+	return nil
+}
+
+func (s *memoryStore) List() ([]ReadmeSummary, error) {
+	s.mu.RLock()
+	stored := make([]*memoryReadme, 0, len(s.readmes))
+	for _, readme := range s.readmes {
+		stored = append(stored, readme)
+	}
+	s.mu.RUnlock()
+
+	summaries := make([]ReadmeSummary, 0, len(stored))
+	for _, readme := range stored {
+		readme.mu.Lock()
+		if !readme.deleted {
+			summaries = append(summaries, readme.readme.summary())
+		}
+		readme.mu.Unlock()
+	}
+
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].NAME < summaries[j].NAME })
+	return summaries, nil
+}
+
+func (s *memoryStore) CreateTemplate(template Template) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.templates[template.NAME]; ok {
+		return ErrTemplateExists
+	}
+
+	s.templates[template.NAME] = template.clone()
+	return nil
+}
+
+func (s *memoryStore) GetTemplate(name string) (Template, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	template, ok := s.templates[name]
+	if !ok {
+		return Template{}, ErrTemplateNotFound
+	}
+
+	return template.clone(), nil
+}
+
+func (s *memoryStore) UpdateTemplate(template Template) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.templates[template.NAME]; !ok {
+		return ErrTemplateNotFound
+	}
+
+	s.templates[template.NAME] = template.clone()
+	return nil
+}
+
+func (s *memoryStore) DeleteTemplate(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.templates[name]; !ok {
+		return ErrTemplateNotFound
+	}
+
+	delete(s.templates, name)
+	return nil
+}
+
+func (s *memoryStore) ListTemplates() ([]Template, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	templates := make([]Template, 0, len(s.templates))
+	for _, template := range s.templates {
+		templates = append(templates, template.clone())
+	}
+
+	sort.Slice(templates, func(i, j int) bool { return templates[i].NAME < templates[j].NAME })
+	return templates, nil
+}
+
+func (s *memoryStore) CreateSnippet(snippet Snippet) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.snippets[snippet.NAME]; ok {
+		return ErrSnippetExists
+	}
+
+	s.snippets[snippet.NAME] = snippet.clone()
+	return nil
+}
+
+func (s *memoryStore) GetSnippet(name string) (Snippet, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snippet, ok := s.snippets[name]
+	if !ok {
+		return Snippet{}, ErrSnippetNotFound
+	}
+
+	return snippet.clone(), nil
+}
+
+func (s *memoryStore) UpdateSnippet(snippet Snippet) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.snippets[snippet.NAME]; !ok {
+		return ErrSnippetNotFound
+	}
+
+	s.snippets[snippet.NAME] = snippet.clone()
+	return nil
+}
+
+func (s *memoryStore) DeleteSnippet(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.snippets[name]; !ok {
+		return ErrSnippetNotFound
+	}
+
+	delete(s.snippets, name)
+	return nil
+}
+
+func (s *memoryStore) ListSnippets() ([]Snippet, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snippets := make([]Snippet, 0, len(s.snippets))
+	for _, snippet := range s.snippets {
+		snippets = append(snippets, snippet.clone())
+	}
+
+	sort.Slice(snippets, func(i, j int) bool { return snippets[i].NAME < snippets[j].NAME })
+	return snippets, nil
+}
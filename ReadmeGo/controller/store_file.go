@@ -0,0 +1,381 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// fileRecord is what is written to a readme file, the readme itself leaves its revisions and undo log out of json
+type fileRecord struct {
+	Readme
+	REVISIONS []Revision  `json:"revisions"`
+	UNDO      []Operation `json:"undo,omitempty"`
+	REDO      []Operation `json:"redo,omitempty"`
+}
+
+// fileStore keeps every readme as a json file inside dir so readmes survive a restart,
+// templates and snippets are kept the same way in the templates and snippets directories inside it
+type fileStore struct {
+	dir       string
+	locks     *keyedMutex
+	documents *keyedMutex
+}
+
+func newFileStore(dir string) (*fileStore, error) {
+	for _, kind := range []string{"templates", "snippets"} {
+		if err := os.MkdirAll(filepath.Join(dir, kind), 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	return &fileStore{dir: dir, locks: newKeyedMutex(), documents: newKeyedMutex()}, nil
+}
+
+// readme ids are user defined so they are encoded before being used as file names
+func (s *fileStore) path(readmeId string) string {
+	return filepath.Join(s.dir, base64.RawURLEncoding.EncodeToString([]byte(readmeId))+".json")
+}
+
+func (s *fileStore) read(readmeId string) (Readme, error) {
+	data, err := os.ReadFile(s.path(readmeId))
+	if errors.Is(err, os.ErrNotExist) {
+		return Readme{}, ErrReadmeNotFound
+	}
+	if err != nil {
+		return Readme{}, err
+	}
+
+	var readme Readme
+
+	// files written before elements were typed hold a list of rendered markdown strings
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		var lines []string
+		if err := json.Unmarshal(data, &lines); err != nil {
+			return Readme{}, err
+		}
+		readme = legacyReadme(readmeId, lines)
+	} else {
+		var record fileRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return Readme{}, err
+		}
+		readme = record.Readme
+		readme.REVISIONS = record.REVISIONS
+		readme.UNDO = record.UNDO
+		readme.REDO = record.REDO
+	}
+
+	if readme.ELEMENTS == nil {
+		readme.ELEMENTS = []Element{}
+	}
+
+	changed := ensureElementIds(&readme)
+
+	// readmes saved before the creation time was kept use the last time the file was written
+	if readme.CREATED_AT.IsZero() {
+		info, err := os.Stat(s.path(readmeId))
+		if err != nil {
+			return Readme{}, err
+		}
+		readme.CREATED_AT = info.ModTime().UTC()
+		changed = true
+	}
+
+	// values filled in on read have to be saved straight away or they would change on every read
+	if changed {
+		if err := s.write(readme); err != nil {
+			return Readme{}, err
+		}
+	}
+
+	return readme, nil
+}
+
+// write replaces the readme file through a rename so a crash never leaves half a readme behind
+func (s *fileStore) write(readme Readme) error {
+	data, err := json.Marshal(fileRecord{Readme: readme, REVISIONS: readme.REVISIONS, UNDO: readme.UNDO, REDO: readme.REDO})
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(s.path(readme.NAME), data)
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it into place
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".readme-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *fileStore) Create(readme Readme) error {
+	defer s.locks.Lock(readme.NAME)()
+
+	if _, err := os.Stat(s.path(readme.NAME)); err == nil {
+		return ErrReadmeExists
+	}
+
+	return s.write(readme)
+}
+
+func (s *fileStore) Get(readmeId string) (Readme, error) {
+	defer s.locks.Lock(readmeId)()
+
+	return s.read(readmeId)
+}
+
+func (s *fileStore) Update(readmeId string, fn func(readme *Readme) error) error {
+	defer s.locks.Lock(readmeId)()
+
+	readme, err := s.read(readmeId)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(&readme); err != nil {
+		return err
+	}
+
+	return s.write(readme)
+}
+
+func (s *fileStore) Append(readmeId string, element Element) error {
+	return s.Update(readmeId, func(readme *Readme) error {
+		readme.ELEMENTS = append(readme.ELEMENTS, element)
+		return nil
+	})
+}
+
+func (s *fileStore) Replace(readmeId string, elements []Element) error {
+	return s.Update(readmeId, func(readme *Readme) error {
+		readme.ELEMENTS = elements
+		return nil
+	})
+}
+
+func (s *fileStore) Delete(readmeId string) error {
+	defer s.locks.Lock(readmeId)()
+
+	err := os.Remove(s.path(readmeId))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrReadmeNotFound
+	}
+
+	return err
+}
+
+func (s *fileStore) List() ([]ReadmeSummary, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := []ReadmeSummary{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+
+		readmeId, err := base64.RawURLEncoding.DecodeString(strings.TrimSuffix(name, ".json"))
+		if err != nil {
+			continue
+		}
+
+		readme, err := s.Get(string(readmeId))
+		// deleted since the directory was read
+		if errors.Is(err, ErrReadmeNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		summaries = append(summaries, readme.summary())
+	}
+
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].NAME < summaries[j].NAME })
+	return summaries, nil
+}
+
+// documentPath returns the file of a template or snippet, kind is the directory they are kept in
+func (s *fileStore) documentPath(kind string, name string) string {
+	return filepath.Join(s.dir, kind, base64.RawURLEncoding.EncodeToString([]byte(name))+".json")
+}
+
+// readDocument decodes the document into v, the caller holds its lock
+func (s *fileStore) readDocument(kind string, name string, v interface{}, notFound error) error {
+	data, err := os.ReadFile(s.documentPath(kind, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return notFound
+	}
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+func (s *fileStore) getDocument(kind string, name string, v interface{}, notFound error) error {
+	defer s.documents.Lock(kind + "/" + name)()
+
+	return s.readDocument(kind, name, v, notFound)
+}
+
+// putDocument writes the document, creating it only when it doesn't exist yet and replacing it only when it does
+func (s *fileStore) putDocument(kind string, name string, v interface{}, create bool, exists error, notFound error) error {
+	defer s.documents.Lock(kind + "/" + name)()
+
+	_, err := os.Stat(s.documentPath(kind, name))
+	if create && err == nil {
+		return exists
+	}
+	if !create && errors.Is(err, os.ErrNotExist) {
+		return notFound
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(s.documentPath(kind, name), data)
+}
+
+func (s *fileStore) deleteDocument(kind string, name string, notFound error) error {
+	defer s.documents.Lock(kind + "/" + name)()
+
+	err := os.Remove(s.documentPath(kind, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return notFound
+	}
+
+	return err
+}
+
+// documentNames returns the names of every document of the kind, sorted
+func (s *fileStore) documentNames(kind string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, kind))
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, entry := range entries {
+		fileName := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(fileName, ".json") {
+			continue
+		}
+
+		name, err := base64.RawURLEncoding.DecodeString(strings.TrimSuffix(fileName, ".json"))
+		if err != nil {
+			continue
+		}
+
+		names = append(names, string(name))
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
+func (s *fileStore) CreateTemplate(template Template) error {
+	return s.putDocument("templates", template.NAME, template, true, ErrTemplateExists, ErrTemplateNotFound)
+}
+
+func (s *fileStore) GetTemplate(name string) (Template, error) {
+	var template Template
+	err := s.getDocument("templates", name, &template, ErrTemplateNotFound)
+	return template, err
+}
+
+func (s *fileStore) UpdateTemplate(template Template) error {
+	return s.putDocument("templates", template.NAME, template, false, ErrTemplateExists, ErrTemplateNotFound)
+}
+
+func (s *fileStore) DeleteTemplate(name string) error {
+	return s.deleteDocument("templates", name, ErrTemplateNotFound)
+}
+
+func (s *fileStore) ListTemplates() ([]Template, error) {
+	names, err := s.documentNames("templates")
+	if err != nil {
+		return nil, err
+	}
+
+	templates := []Template{}
+	for _, name := range names {
+		template, err := s.GetTemplate(name)
+		// deleted since the directory was read
+		if errors.Is(err, ErrTemplateNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		templates = append(templates, template)
+	}
+
+	return templates, nil
+}
+
+func (s *fileStore) CreateSnippet(snippet Snippet) error {
+	return s.putDocument("snippets", snippet.NAME, snippet, true, ErrSnippetExists, ErrSnippetNotFound)
+}
+
+func (s *fileStore) GetSnippet(name string) (Snippet, error) {
+	var snippet Snippet
+	err := s.getDocument("snippets", name, &snippet, ErrSnippetNotFound)
+	return snippet, err
+}
+
+func (s *fileStore) UpdateSnippet(snippet Snippet) error {
+	return s.putDocument("snippets", snippet.NAME, snippet, false, ErrSnippetExists, ErrSnippetNotFound)
+}
+
+func (s *fileStore) DeleteSnippet(name string) error {
+	return s.deleteDocument("snippets", name, ErrSnippetNotFound)
+}
+
+func (s *fileStore) ListSnippets() ([]Snippet, error) {
+	names, err := s.documentNames("snippets")
+	if err != nil {
+		return nil, err
+	}
+
+	snippets := []Snippet{}
+	for _, name := range names {
+		snippet, err := s.GetSnippet(name)
+		// deleted since the directory was read
+		if errors.Is(err, ErrSnippetNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		snippets = append(snippets, snippet)
+	}
+
+	return snippets, nil
+}
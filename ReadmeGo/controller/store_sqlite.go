@@ -0,0 +1,611 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3" // registers the sqlite3 database/sql driver
+)
+
+// sqliteMigrations are applied in order on startup, the index + 1 is the schema version.
+// Never edit a migration that has been released, add a new one instead.
+var sqliteMigrations = []string{
+	`CREATE TABLE readmes (
+		id TEXT PRIMARY KEY,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE readme_lines (
+		readme_id TEXT NOT NULL REFERENCES readmes(id) ON DELETE CASCADE,
+		position INTEGER NOT NULL,
+		value TEXT NOT NULL,
+		PRIMARY KEY (readme_id, position)
+	);`,
+	// readmes are stored as typed elements, rendered lines from version 1 become raw elements
+	`CREATE TABLE readme_elements (
+		readme_id TEXT NOT NULL REFERENCES readmes(id) ON DELETE CASCADE,
+		position INTEGER NOT NULL,
+		element TEXT NOT NULL,
+		PRIMARY KEY (readme_id, position)
+	);
+	INSERT INTO readme_elements (readme_id, position, element)
+		SELECT readme_id, position, json_object('type', 'raw', 'value', value)
+		FROM readme_lines WHERE value != '';
+	DROP TABLE readme_lines;`,
+	// every element gets a stable id
+	`UPDATE readme_elements SET element = json_set(element, '$.id', lower(hex(randomblob(16))))
+		WHERE json_extract(element, '$.id') IS NULL;`,
+	`CREATE TABLE readme_revisions (
+		readme_id TEXT NOT NULL REFERENCES readmes(id) ON DELETE CASCADE,
+		number INTEGER NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		author TEXT NOT NULL,
+		operation TEXT NOT NULL,
+		elements TEXT NOT NULL,
+		PRIMARY KEY (readme_id, number)
+	);`,
+	// the undo and redo logs are small and bounded so they are kept as json next to the readme
+	`ALTER TABLE readmes ADD COLUMN undo TEXT NOT NULL DEFAULT '[]';
+	ALTER TABLE readmes ADD COLUMN redo TEXT NOT NULL DEFAULT '[]';`,
+	`CREATE TABLE templates (
+		name TEXT PRIMARY KEY,
+		template TEXT NOT NULL
+	);`,
+	// json of the readme variables, null while the readme renders as written
+	`ALTER TABLE readmes ADD COLUMN variables TEXT NOT NULL DEFAULT 'null';`,
+	`CREATE TABLE snippets (
+		name TEXT PRIMARY KEY,
+		snippet TEXT NOT NULL
+	);`,
+}
+
+// sqliteStore keeps readmes in an embedded sqlite database
+type sqliteStore struct {
+	db *sql.DB
+}
+
+func newSqliteStore(path string) (*sqliteStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite3", "file:"+path+"?_foreign_keys=on&_busy_timeout=5000")
+	if err != nil {
+		return nil, err
+	}
+
+	// sqlite only allows one writer, a single connection avoids "database is locked" errors
+	// and means transactions on the same readme never interleave
+	db.SetMaxOpenConns(1)
+
+	if err := migrateSqlite(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &sqliteStore{db: db}, nil
+}
+
+// migrateSqlite brings the schema up to date, each migration runs in its own transaction
+func migrateSqlite(db *sql.DB) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`); err != nil {
+		return err
+	}
+
+	var version int
+	if err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+		return err
+	}
+
+	if version > len(sqliteMigrations) {
+		return fmt.Errorf("database schema version %d is newer than this build supports (%d)", version, len(sqliteMigrations))
+	}
+
+	for i := version; i < len(sqliteMigrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(sqliteMigrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version) VALUES (?)`, i+1); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *sqliteStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *sqliteStore) exists(tx *sql.Tx, readmeId string) error {
+	var found int
+	err := tx.QueryRow(`SELECT 1 FROM readmes WHERE id = ?`, readmeId).Scan(&found)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrReadmeNotFound
+	}
+
+	return err
+}
+
+func insertElements(tx *sql.Tx, readmeId string, start int, elements []Element) error {
+	for i, element := range elements {
+		data, err := json.Marshal(element)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(`INSERT INTO readme_elements (readme_id, position, element) VALUES (?, ?, ?)`, readmeId, start+i, string(data)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// insertRevisions saves the revisions numbered after the last one already saved, revisions never change once
+// written. Saved revisions older than the first one given have been dropped from the readme and are deleted.
+func insertRevisions(tx *sql.Tx, readmeId string, revisions []Revision) error {
+	if len(revisions) == 0 {
+		return nil
+	}
+
+	if _, err := tx.Exec(`DELETE FROM readme_revisions WHERE readme_id = ? AND number < ?`, readmeId, revisions[0].NUMBER); err != nil {
+		return err
+	}
+
+	var saved int
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(number), 0) FROM readme_revisions WHERE readme_id = ?`, readmeId).Scan(&saved); err != nil {
+		return err
+	}
+
+	for _, revision := range revisions {
+		if revision.NUMBER <= saved {
+			continue
+		}
+
+		elements, err := json.Marshal(revision.ELEMENTS)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`INSERT INTO readme_revisions (readme_id, number, created_at, author, operation, elements) VALUES (?, ?, ?, ?, ?, ?)`,
+			readmeId, revision.NUMBER, revision.CREATED_AT.UTC(), revision.AUTHOR, revision.OPERATION, string(elements))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// saveOperations writes the undo and redo logs of the readme
+func saveOperations(tx *sql.Tx, readme Readme) error {
+	undo, err := json.Marshal(readme.UNDO)
+	if err != nil {
+		return err
+	}
+
+	redo, err := json.Marshal(readme.REDO)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE readmes SET undo = ?, redo = ? WHERE id = ?`, string(undo), string(redo), readme.NAME)
+	return err
+}
+
+// saveVariables writes the variables of the readme
+func saveVariables(tx *sql.Tx, readme Readme) error {
+	variables, err := json.Marshal(readme.VARIABLES)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE readmes SET variables = ? WHERE id = ?`, string(variables), readme.NAME)
+	return err
+}
+
+func revisions(tx *sql.Tx, readmeId string) ([]Revision, error) {
+	rows, err := tx.Query(`SELECT number, created_at, author, operation, elements FROM readme_revisions WHERE readme_id = ? ORDER BY number`, readmeId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []Revision
+	for rows.Next() {
+		var revision Revision
+		var elements string
+		if err := rows.Scan(&revision.NUMBER, &revision.CREATED_AT, &revision.AUTHOR, &revision.OPERATION, &elements); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal([]byte(elements), &revision.ELEMENTS); err != nil {
+			return nil, err
+		}
+
+		revision.CREATED_AT = revision.CREATED_AT.UTC()
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, rows.Err()
+}
+
+// inTx runs fn in a transaction and commits only if fn succeeds
+func (s *sqliteStore) inTx(fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *sqliteStore) Create(readme Readme) error {
+	return s.inTx(func(tx *sql.Tx) error {
+		err := s.exists(tx, readme.NAME)
+		if err == nil {
+			return ErrReadmeExists
+		}
+		if !errors.Is(err, ErrReadmeNotFound) {
+			return err
+		}
+
+		if _, err := tx.Exec(`INSERT INTO readmes (id, created_at) VALUES (?, ?)`, readme.NAME, readme.CREATED_AT.UTC()); err != nil {
+			return err
+		}
+
+		if err := insertElements(tx, readme.NAME, 0, readme.ELEMENTS); err != nil {
+			return err
+		}
+
+		if err := saveOperations(tx, readme); err != nil {
+			return err
+		}
+
+		if err := saveVariables(tx, readme); err != nil {
+			return err
+		}
+
+		return insertRevisions(tx, readme.NAME, readme.REVISIONS)
+	})
+}
+
+func (s *sqliteStore) readme(tx *sql.Tx, readmeId string) (Readme, error) {
+	var createdAt time.Time
+	var undo, redo, variables string
+	err := tx.QueryRow(`SELECT created_at, undo, redo, variables FROM readmes WHERE id = ?`, readmeId).Scan(&createdAt, &undo, &redo, &variables)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Readme{}, ErrReadmeNotFound
+	}
+	if err != nil {
+		return Readme{}, err
+	}
+
+	rows, err := tx.Query(`SELECT element FROM readme_elements WHERE readme_id = ? ORDER BY position`, readmeId)
+	if err != nil {
+		return Readme{}, err
+	}
+	defer rows.Close()
+
+	readme := Readme{NAME: readmeId, CREATED_AT: createdAt.UTC(), ELEMENTS: []Element{}}
+	if err := json.Unmarshal([]byte(undo), &readme.UNDO); err != nil {
+		return Readme{}, err
+	}
+	if err := json.Unmarshal([]byte(redo), &readme.REDO); err != nil {
+		return Readme{}, err
+	}
+	if err := json.Unmarshal([]byte(variables), &readme.VARIABLES); err != nil {
+		return Readme{}, err
+	}
+
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return Readme{}, err
+		}
+
+		var element Element
+		if err := json.Unmarshal([]byte(data), &element); err != nil {
+			return Readme{}, err
+		}
+		readme.ELEMENTS = append(readme.ELEMENTS, element)
+	}
+
+	if err := rows.Err(); err != nil {
+		return Readme{}, err
+	}
+
+	readme.REVISIONS, err = revisions(tx, readmeId)
+	return readme, err
+}
+
+func (s *sqliteStore) Get(readmeId string) (Readme, error) {
+	var readme Readme
+
+	err := s.inTx(func(tx *sql.Tx) error {
+		var err error
+		readme, err = s.readme(tx, readmeId)
+		return err
+	})
+
+	return readme, err
+}
+
+func (s *sqliteStore) Update(readmeId string, fn func(readme *Readme) error) error {
+	return s.inTx(func(tx *sql.Tx) error {
+		readme, err := s.readme(tx, readmeId)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(&readme); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(`DELETE FROM readme_elements WHERE readme_id = ?`, readmeId); err != nil {
+			return err
+		}
+
+		if err := insertElements(tx, readmeId, 0, readme.ELEMENTS); err != nil {
+			return err
+		}
+
+		if err := saveOperations(tx, readme); err != nil {
+			return err
+		}
+
+		if err := saveVariables(tx, readme); err != nil {
+			return err
+		}
+
+		return insertRevisions(tx, readmeId, readme.REVISIONS)
+	})
+}
+
+func (s *sqliteStore) Append(readmeId string, element Element) error {
+	return s.inTx(func(tx *sql.Tx) error {
+		if err := s.exists(tx, readmeId); err != nil {
+			return err
+		}
+
+		var next int
+		if err := tx.QueryRow(`SELECT COALESCE(MAX(position) + 1, 0) FROM readme_elements WHERE readme_id = ?`, readmeId).Scan(&next); err != nil {
+			return err
+		}
+
+		return insertElements(tx, readmeId, next, []Element{element})
+	})
+}
+
+func (s *sqliteStore) Replace(readmeId string, elements []Element) error {
+	return s.Update(readmeId, func(readme *Readme) error {
+		readme.ELEMENTS = elements
+		return nil
+	})
+}
+
+func (s *sqliteStore) Delete(readmeId string) error {
+	result, err := s.db.Exec(`DELETE FROM readmes WHERE id = ?`, readmeId)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if deleted == 0 {
+		return ErrReadmeNotFound
+	}
+
+	return nil
+}
+
+func (s *sqliteStore) List() ([]ReadmeSummary, error) {
+	rows, err := s.db.Query(`SELECT id, created_at FROM readmes ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := []ReadmeSummary{}
+	for rows.Next() {
+		var summary ReadmeSummary
+		if err := rows.Scan(&summary.NAME, &summary.CREATED_AT); err != nil {
+			return nil, err
+		}
+		summary.CREATED_AT = summary.CREATED_AT.UTC()
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, rows.Err()
+}
+
+func (s *sqliteStore) CreateTemplate(template Template) error {
+	data, err := json.Marshal(template)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.db.Exec(`INSERT INTO templates (name, template) VALUES (?, ?) ON CONFLICT (name) DO NOTHING`, template.NAME, string(data))
+	if err != nil {
+		return err
+	}
+
+	return rowChanged(result, ErrTemplateExists)
+}
+
+func (s *sqliteStore) GetTemplate(name string) (Template, error) {
+	var data string
+	err := s.db.QueryRow(`SELECT template FROM templates WHERE name = ?`, name).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Template{}, ErrTemplateNotFound
+	}
+	if err != nil {
+		return Template{}, err
+	}
+
+	var template Template
+	err = json.Unmarshal([]byte(data), &template)
+	return template, err
+}
+
+func (s *sqliteStore) UpdateTemplate(template Template) error {
+	data, err := json.Marshal(template)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.db.Exec(`UPDATE templates SET template = ? WHERE name = ?`, string(data), template.NAME)
+	if err != nil {
+		return err
+	}
+
+	return rowChanged(result, ErrTemplateNotFound)
+}
+
+func (s *sqliteStore) DeleteTemplate(name string) error {
+	result, err := s.db.Exec(`DELETE FROM templates WHERE name = ?`, name)
+	if err != nil {
+		return err
+	}
+
+	return rowChanged(result, ErrTemplateNotFound)
+}
+
+// rowChanged returns unchanged when the statement didn't change any row
+func rowChanged(result sql.Result, unchanged error) error {
+	changed, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if changed == 0 {
+		return unchanged
+	}
+
+	return nil
+}
+
+func (s *sqliteStore) ListTemplates() ([]Template, error) {
+	rows, err := s.db.Query(`SELECT template FROM templates ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := []Template{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+
+		var template Template
+		if err := json.Unmarshal([]byte(data), &template); err != nil {
+			return nil, err
+		}
+		templates = append(templates, template)
+	}
+
+	return templates, rows.Err()
+}
+
+func (s *sqliteStore) CreateSnippet(snippet Snippet) error {
+	data, err := json.Marshal(snippet)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.db.Exec(`INSERT INTO snippets (name, snippet) VALUES (?, ?) ON CONFLICT (name) DO NOTHING`, snippet.NAME, string(data))
+	if err != nil {
+		return err
+	}
+
+	return rowChanged(result, ErrSnippetExists)
+}
+
+func (s *sqliteStore) GetSnippet(name string) (Snippet, error) {
+	var data string
+	err := s.db.QueryRow(`SELECT snippet FROM snippets WHERE name = ?`, name).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Snippet{}, ErrSnippetNotFound
+	}
+	if err != nil {
+		return Snippet{}, err
+	}
+
+	var snippet Snippet
+	err = json.Unmarshal([]byte(data), &snippet)
+	return snippet, err
+}
+
+func (s *sqliteStore) UpdateSnippet(snippet Snippet) error {
+	data, err := json.Marshal(snippet)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.db.Exec(`UPDATE snippets SET snippet = ? WHERE name = ?`, string(data), snippet.NAME)
+	if err != nil {
+		return err
+	}
+
+	return rowChanged(result, ErrSnippetNotFound)
+}
+
+func (s *sqliteStore) DeleteSnippet(name string) error {
+	result, err := s.db.Exec(`DELETE FROM snippets WHERE name = ?`, name)
+	if err != nil {
+		return err
+	}
+
+	return rowChanged(result, ErrSnippetNotFound)
+}
+
+func (s *sqliteStore) ListSnippets() ([]Snippet, error) {
+	rows, err := s.db.Query(`SELECT snippet FROM snippets ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snippets := []Snippet{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+
+		var snippet Snippet
+		if err := json.Unmarshal([]byte(data), &snippet); err != nil {
+			return nil, err
+		}
+		snippets = append(snippets, snippet)
+	}
+
+	return snippets, rows.Err()
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testStores(t *testing.T) map[string]ReadmeStore {
+	fileStore, err := newFileStore(t.TempDir())
+	require.NoError(t, err)
+
+	sqliteStore, err := newSqliteStore(filepath.Join(t.TempDir(), "readme.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { sqliteStore.Close() })
+
+	return map[string]ReadmeStore{
+		"memory": newMemoryStore(),
+		"file":   fileStore,
+		"sqlite": sqliteStore,
+	}
+}
+
+func TestReadmeStore(t *testing.T) {
+	created := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	header := Element{ID: "h", TYPE: HeaderElement, LEVEL: 1, VALUE: "header"}
+	quote := Element{ID: "q", TYPE: BlockquoteElement, VALUE: "quote"}
+
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, store.Create(Readme{NAME: "1", CREATED_AT: created, ELEMENTS: []Element{}}))
+			require.ErrorIs(t, store.Create(Readme{NAME: "1", CREATED_AT: created}), ErrReadmeExists)
+
+			require.NoError(t, store.Append("1", header))
+			readme, err := store.Get("1")
+			require.NoError(t, err)
+			require.Equal(t, Readme{NAME: "1", CREATED_AT: created, ELEMENTS: []Element{header}}, readme)
+
+			require.NoError(t, store.Replace("1", []Element{quote}))
+			readme, err = store.Get("1")
+			require.NoError(t, err)
+			require.Equal(t, []Element{quote}, readme.ELEMENTS)
+
+			require.NoError(t, store.Create(Readme{NAME: "../2", CREATED_AT: created.Add(time.Hour)}))
+			summaries, err := store.List()
+			require.NoError(t, err)
+			require.Equal(t, []ReadmeSummary{{NAME: "../2", CREATED_AT: created.Add(time.Hour)}, {NAME: "1", CREATED_AT: created}}, summaries)
+
+			require.NoError(t, store.Delete("1"))
+			_, err = store.Get("1")
+			require.ErrorIs(t, err, ErrReadmeNotFound)
+			require.ErrorIs(t, store.Append("1", header), ErrReadmeNotFound)
+			require.ErrorIs(t, store.Replace("1", nil), ErrReadmeNotFound)
+			require.ErrorIs(t, store.Delete("1"), ErrReadmeNotFound)
+		})
+	}
+}
+
+func TestReadmeStoreUpdateErrorLeavesReadmeUntouched(t *testing.T) {
+	table := Element{ID: "t", TYPE: TableElement, COLUMN_NAMES: []string{"c1"}, COLUMN_VALUES: map[string][]string{"c1": {"v1"}}}
+
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, store.Create(Readme{NAME: "1", CREATED_AT: time.Now().UTC(), ELEMENTS: []Element{table}}))
+
+			err := store.Update("1", func(readme *Readme) error {
+				readme.ELEMENTS[0].COLUMN_VALUES["c1"][0] = "changed"
+				readme.ELEMENTS = nil
+				return errors.New("failed")
+			})
+			require.EqualError(t, err, "failed")
+
+			readme, err := store.Get("1")
+			require.NoError(t, err)
+			require.Equal(t, []Element{table}, readme.ELEMENTS)
+		})
+	}
+}
+
+func TestFileStoreSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	w := httptest.NewRecorder()
+	r := httptest.NewRecorder()
+
+	store, err := newFileStore(dir)
+	require.NoError(t, err)
+	router := setupRouter(store)
+
+	req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+	router.ServeHTTP(w, req1)
+
+	req2, _ := http.NewRequest("PUT", "/readme/1/paragraph?paragraph=still here", nil)
+	router.ServeHTTP(w, req2)
+
+	restartedStore, err := newFileStore(dir)
+	require.NoError(t, err)
+	router = setupRouter(restartedStore)
+
+	req3, _ := http.NewRequest("GET", "/readme/1", nil)
+	router.ServeHTTP(r, req3)
+
+	require.JSONEq(t, string(`["still here\n"]`), r.Body.String())
+}
+
+func TestNewReadmeStoreRejectsUnknownStore(t *testing.T) {
+	_, err := newReadmeStore("s3", "")
+	require.Error(t, err)
+}
+
+func TestSqliteStoreMigrationsAreAppliedOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "readme.db")
+
+	store, err := newSqliteStore(path)
+	require.NoError(t, err)
+	require.NoError(t, store.Create(Readme{NAME: "1", ELEMENTS: []Element{{TYPE: HeaderElement, LEVEL: 1, VALUE: "header"}}}))
+	require.NoError(t, store.Close())
+
+	reopened, err := newSqliteStore(path)
+	require.NoError(t, err)
+	defer reopened.Close()
+
+	var version int
+	require.NoError(t, reopened.db.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&version))
+	require.Equal(t, len(sqliteMigrations), version)
+
+	readme, err := reopened.Get("1")
+	require.NoError(t, err)
+	require.Equal(t, "# header\n", renderReadme(readme))
+}
+
+func TestSqliteStoreMigratesRenderedLinesToRawElements(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "readme.db")
+
+	db, err := sql.Open("sqlite3", "file:"+path)
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE schema_migrations (version INTEGER PRIMARY KEY); INSERT INTO schema_migrations VALUES (1);`)
+	require.NoError(t, err)
+	_, err = db.Exec(sqliteMigrations[0])
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO readmes (id) VALUES ('1')`)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO readme_lines VALUES ('1', 0, ''), ('1', 1, ?)`, "# header\n")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	store, err := newSqliteStore(path)
+	require.NoError(t, err)
+	defer store.Close()
+
+	readme, err := store.Get("1")
+	require.NoError(t, err)
+	require.Len(t, readme.ELEMENTS, 1)
+	require.NotEmpty(t, readme.ELEMENTS[0].ID)
+	require.Equal(t, Element{ID: readme.ELEMENTS[0].ID, TYPE: RawElement, VALUE: "# header\n"}, readme.ELEMENTS[0])
+
+	again, err := store.Get("1")
+	require.NoError(t, err)
+	require.Equal(t, readme, again)
+}
+
+func TestFileStoreReadsRenderedLines(t *testing.T) {
+	store, err := newFileStore(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(store.path("1"), []byte(`["", "# header\n"]`), 0644))
+
+	readme, err := store.Get("1")
+	require.NoError(t, err)
+	require.Len(t, readme.ELEMENTS, 1)
+	require.False(t, readme.CREATED_AT.IsZero())
+	require.Equal(t, Readme{NAME: "1", CREATED_AT: readme.CREATED_AT, ELEMENTS: []Element{{ID: readme.ELEMENTS[0].ID, TYPE: RawElement, VALUE: "# header\n"}}}, readme)
+
+	again, err := store.Get("1")
+	require.NoError(t, err)
+	require.Equal(t, readme, again)
+}
+
+// TestConcurrentWrites is meant to be run with go test -race, every client appends to the same
+// readme at once and no write may be lost or reordered within a client
+func TestConcurrentWrites(t *testing.T) {
+	const clients = 8
+	const requestsPerClient = 20
+
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			router := setupRouter(store)
+
+			req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+			router.ServeHTTP(httptest.NewRecorder(), req1)
+
+			var wg sync.WaitGroup
+			for client := 0; client < clients; client++ {
+				wg.Add(1)
+				go func(client int) {
+					defer wg.Done()
+					for i := 0; i < requestsPerClient; i++ {
+						r := httptest.NewRecorder()
+						var req *http.Request
+						if i%2 == 0 {
+							req, _ = http.NewRequest("PUT", fmt.Sprintf("/readme/1/paragraph?paragraph=%d-%d", client, i), nil)
+						} else {
+							body := []byte(fmt.Sprintf(`{"header_type": "SMALL_HEADING", "value": "%d-%d"}`, client, i))
+							req, _ = http.NewRequest("PUT", "/readme/1/header", bytes.NewBuffer(body))
+						}
+						router.ServeHTTP(r, req)
+						if r.Code != http.StatusOK {
+							t.Errorf("client %d request %d returned %d", client, i, r.Code)
+						}
+					}
+				}(client)
+			}
+			wg.Wait()
+
+			r := httptest.NewRecorder()
+			req2, _ := http.NewRequest("GET", "/readme/1", nil)
+			router.ServeHTTP(r, req2)
+
+			var lines []string
+			require.NoError(t, json.Unmarshal(r.Body.Bytes(), &lines))
+			require.Len(t, lines, clients*requestsPerClient)
+
+			next := make([]int, clients)
+			for _, line := range lines {
+				var client, i int
+				_, err := fmt.Sscanf(strings.TrimPrefix(line, "### "), "%d-%d", &client, &i)
+				require.NoError(t, err)
+				require.Equal(t, next[client], i, "client %d writes out of order", client)
+				next[client]++
+			}
+		})
+	}
+}
+
+func TestConcurrentCreateOnlyOneWins(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			router := setupRouter(store)
+			codes := make(chan int, 10)
+
+			var wg sync.WaitGroup
+			for i := 0; i < cap(codes); i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					r := httptest.NewRecorder()
+					req, _ := http.NewRequest("POST", "/readme?name=1", nil)
+					router.ServeHTTP(r, req)
+					codes <- r.Code
+				}()
+			}
+			wg.Wait()
+			close(codes)
+
+			created := 0
+			for code := range codes {
+				if code == http.StatusCreated {
+					created++
+				} else {
+					require.Equal(t, http.StatusConflict, code)
+				}
+			}
+			require.Equal(t, 1, created)
+		})
+	}
+}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var ErrTemplateNotFound = errors.New("could not find template")
+var ErrTemplateExists = errors.New("template with that name already exists")
+
+// TemplateStore keeps the templates teams publish, the built in templates are never stored.
+// Every ReadmeStore is also a TemplateStore so templates live next to the readmes.
+type TemplateStore interface {
+	CreateTemplate(template Template) error
+	GetTemplate(name string) (Template, error)
+	// UpdateTemplate replaces the stored template with the same name
+	UpdateTemplate(template Template) error
+	DeleteTemplate(name string) error
+	// ListTemplates returns every stored template ordered by name
+	ListTemplates() ([]Template, error)
+}
+
+// Template is a named list of placeholder elements a new readme can start from
+type Template struct {
+	NAME        string    `json:"name" binding:"required"`
+	DESCRIPTION string    `json:"description"`
+	BUILTIN     bool      `json:"builtin"`
+	ELEMENTS    []Element `json:"elements" binding:"required"`
+}
+
+func (t Template) clone() Template {
+	t.ELEMENTS = Readme{ELEMENTS: t.ELEMENTS}.clone().ELEMENTS
+	return t
+}
+
+// builtinTemplates ship with the server and cannot be changed or deleted, the {{.Name}} placeholders are
+// filled in from the variables of the readme created from them
+var builtinTemplates = map[string]Template{
+	"go-library": {
+		NAME:        "go-library",
+		DESCRIPTION: "A Go package imported by other projects",
+		BUILTIN:     true,
+		ELEMENTS: []Element{
+			{TYPE: HeaderElement, LEVEL: 1, VALUE: "{{.ProjectName}}"},
+			{TYPE: RawElement, VALUE: "[![Go Reference](https://pkg.go.dev/badge/{{.ModulePath}}.svg)](https://pkg.go.dev/{{.ModulePath}})\n"},
+			{TYPE: ParagraphElement, VALUE: "One or two sentences on what the package does and why you would use it."},
+			{TYPE: HeaderElement, LEVEL: 2, VALUE: "Install"},
+			{TYPE: RawElement, VALUE: "```sh\ngo get {{.ModulePath}}\n```\n"},
+			{TYPE: HeaderElement, LEVEL: 2, VALUE: "Usage"},
+			{TYPE: RawElement, VALUE: "```go\nimport \"{{.ModulePath}}\"\n```\n"},
+			{TYPE: HeaderElement, LEVEL: 2, VALUE: "Contributing"},
+			{TYPE: ParagraphElement, VALUE: "Pull requests are welcome. Run `go test ./...` before opening one."},
+			{TYPE: HeaderElement, LEVEL: 2, VALUE: "License"},
+			{TYPE: ParagraphElement, VALUE: "{{.License}}, see LICENSE."},
+		},
+	},
+	"cli": {
+		NAME:        "cli",
+		DESCRIPTION: "A command line tool",
+		BUILTIN:     true,
+		ELEMENTS: []Element{
+			{TYPE: HeaderElement, LEVEL: 1, VALUE: "{{.ProjectName}}"},
+			{TYPE: RawElement, VALUE: "![Build](https://example.com/badge.svg)\n"},
+			{TYPE: ParagraphElement, VALUE: "One or two sentences on what the tool does."},
+			{TYPE: HeaderElement, LEVEL: 2, VALUE: "Install"},
+			{TYPE: RawElement, VALUE: "```sh\ngo install {{.ModulePath}}@latest\n```\n"},
+			{TYPE: HeaderElement, LEVEL: 2, VALUE: "Usage"},
+			{TYPE: RawElement, VALUE: "```sh\n{{.ProjectName}} [flags] <args>\n```\n"},
+			{TYPE: HeaderElement, LEVEL: 2, VALUE: "Flags"},
+			{TYPE: TableElement, COLUMN_NAMES: []string{"Flag", "Default", "Description"}, COLUMN_VALUES: map[string][]string{
+				"Flag":        {"-flag"},
+				"Default":     {"value"},
+				"Description": {"what the flag changes"},
+			}},
+			{TYPE: HeaderElement, LEVEL: 2, VALUE: "Contributing"},
+			{TYPE: ParagraphElement, VALUE: "How to report bugs and send changes."},
+			{TYPE: HeaderElement, LEVEL: 2, VALUE: "License"},
+			{TYPE: ParagraphElement, VALUE: "{{.License}}, see LICENSE."},
+		},
+	},
+	"service": {
+		NAME:        "service",
+		DESCRIPTION: "A service deployed on its own, configured through the environment",
+		BUILTIN:     true,
+		ELEMENTS: []Element{
+			{TYPE: HeaderElement, LEVEL: 1, VALUE: "{{.ProjectName}}"},
+			{TYPE: RawElement, VALUE: "![Build](https://example.com/badge.svg)\n"},
+			{TYPE: ParagraphElement, VALUE: "What the service is responsible for and who calls it."},
+			{TYPE: HeaderElement, LEVEL: 2, VALUE: "Running"},
+			{TYPE: RawElement, VALUE: "```sh\ndocker run -p 8080:8080 {{.Image}}\n```\n"},
+			{TYPE: HeaderElement, LEVEL: 2, VALUE: "Configuration"},
+			{TYPE: TableElement, COLUMN_NAMES: []string{"Variable", "Default", "Description"}, COLUMN_VALUES: map[string][]string{
+				"Variable":    {"PORT"},
+				"Default":     {"8080"},
+				"Description": {"port the service listens on"},
+			}},
+			{TYPE: HeaderElement, LEVEL: 2, VALUE: "Contributing"},
+			{TYPE: ParagraphElement, VALUE: "How to run the service locally and send changes."},
+			{TYPE: HeaderElement, LEVEL: 2, VALUE: "License"},
+			{TYPE: ParagraphElement, VALUE: "{{.License}}, see LICENSE."},
+		},
+	},
+}
+
+// template returns the built in or stored template with that name
+func (rc *readmeController) template(name string) (Template, error) {
+	if template, ok := builtinTemplates[name]; ok {
+		return template.clone(), nil
+	}
+
+	return rc.store.GetTemplate(name)
+}
+
+// templateElements copies the template elements with new ids for a readme
+func templateElements(template Template) []Element {
+	elements := Readme{ELEMENTS: template.ELEMENTS}.clone().ELEMENTS
+	for i := range elements {
+		elements[i].ID = uuid.NewString()
+	}
+
+	return elements
+}
+
+// validateTemplate checks a template before it is stored, element ids are dropped as every readme gets its own
+func validateTemplate(template *Template) error {
+	if strings.TrimSpace(template.NAME) == "" {
+		return invalidRequestError{"template needs a name"}
+	}
+
+	if _, ok := builtinTemplates[template.NAME]; ok {
+		return ErrTemplateExists
+	}
+
+	if template.ELEMENTS == nil {
+		template.ELEMENTS = []Element{}
+	}
+
+	for i := range template.ELEMENTS {
+		if err := validateElement(template.ELEMENTS[i]); err != nil {
+			return err
+		}
+		template.ELEMENTS[i].ID = ""
+	}
+
+	// readmes created from the template fill in its variables so every element has to be a valid template
+	if _, err := undefinedVariables(template.ELEMENTS, nil); err != nil {
+		return invalidRequestError{err.Error()}
+	}
+
+	template.BUILTIN = false
+	return nil
+}
+
+// respondTemplateError is respondStoreError for templates
+func respondTemplateError(c *gin.Context, err error) {
+	if errors.Is(err, ErrTemplateNotFound) {
+		c.IndentedJSON(http.StatusNotFound, HttpErrorMessage{MESSAGE: "could not find template"})
+		return
+	}
+
+	if errors.Is(err, ErrTemplateExists) {
+		c.IndentedJSON(http.StatusConflict, HttpErrorMessage{MESSAGE: "Template with that name already exists"})
+		return
+	}
+
+	respondStoreError(c, err)
+}
+
+// ListTemplates godoc
+// @Summary Lists the templates
+// @Description Returns the built in templates and the ones teams published, ordered by name
+// @Accept json
+// @Produce json
+// @Success	200	{array}		Template	"every template"
+// @Router	/template	[get]
+func (rc *readmeController) listTemplates(c *gin.Context) {
+	templates, err := rc.store.ListTemplates()
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	for _, template := range builtinTemplates {
+		templates = append(templates, template.clone())
+	}
+
+	sort.Slice(templates, func(i, j int) bool { return templates[i].NAME < templates[j].NAME })
+	c.IndentedJSON(http.StatusOK, templates)
+}
+
+// GetTemplate godoc
+// @Summary Returns a template
+// @Accept json
+// @Produce json
+// @Param	name	path	string	true	"template name"
+// @Success	200	{object}	Template	"the template"
+// @Failure 404	{object}	HttpErrorMessage	"could not find template"
+// @Router	/template/{name}	[get]
+func (rc *readmeController) getTemplate(c *gin.Context) {
+	template, err := rc.template(c.Param("name"))
+	if err != nil {
+		respondTemplateError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, template)
+}
+
+// CreateTemplate godoc
+// @Summary Publishes a template
+// @Description Stores a template new readmes can be created from with POST /readme?template=name
+// @Accept json
+// @Produce json
+// @Param	template	body	Template	true	"the template"
+// @Success	201	{object}	HttpMessage	"returns the template name"
+// @Failure 400	{object}	HttpErrorMessage	"incorrect request body"
+// @Failure 409	{object}	HttpErrorMessage	"Template already exists"
+// @Failure 422	{object}	HttpErrorMessage	"the template includes snippets that don't exist"
+// @Router	/template	[post]
+func (rc *readmeController) createTemplate(c *gin.Context) {
+	var template Template
+
+	if err := c.BindJSON(&template); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, HttpErrorMessage{MESSAGE: "incorrect request body, should be Template body"})
+		return
+	}
+
+	if err := validateTemplate(&template); err != nil {
+		respondTemplateError(c, err)
+		return
+	}
+
+	if err := rc.saveTemplate(template, rc.store.CreateTemplate); err != nil {
+		respondTemplateError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusCreated, HttpMessage{MESSAGE: template.NAME})
+}
+
+// saveTemplate checks the snippets the template includes exist while save stores it
+func (rc *readmeController) saveTemplate(template Template, save func(template Template) error) error {
+	rc.snippets.RLock()
+	defer rc.snippets.RUnlock()
+
+	if err := rc.missingSnippets(snippetNames(template.ELEMENTS)); err != nil {
+		return err
+	}
+
+	return save(template)
+}
+
+// UpdateTemplate godoc
+// @Summary Replaces a template
+// @Description Replaces the elements and description of a published template, built in templates cannot be changed.
+// @Description Readmes already created from the template keep their elements.
+// @Accept json
+// @Produce json
+// @Param	name	path	string	true	"template name"
+// @Param	template	body	Template	true	"the new template, its name is taken from the path"
+// @Success	200	{object}	HttpMessage	"returns the template name"
+// @Failure 400	{object}	HttpErrorMessage	"incorrect request body"
+// @Failure 403	{object}	HttpErrorMessage	"built in templates cannot be changed"
+// @Failure 404	{object}	HttpErrorMessage	"could not find template"
+// @Failure 422	{object}	HttpErrorMessage	"the template includes snippets that don't exist"
+// @Router	/template/{name}	[put]
+func (rc *readmeController) updateTemplate(c *gin.Context) {
+	name := c.Param("name")
+	var template Template
+
+	if _, ok := builtinTemplates[name]; ok {
+		c.IndentedJSON(http.StatusForbidden, HttpErrorMessage{MESSAGE: "built in templates cannot be changed"})
+		return
+	}
+
+	// the name can be left out of the body
+	template.NAME = name
+	if err := c.BindJSON(&template); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, HttpErrorMessage{MESSAGE: "incorrect request body, should be Template body"})
+		return
+	}
+	template.NAME = name
+
+	if err := validateTemplate(&template); err != nil {
+		respondTemplateError(c, err)
+		return
+	}
+
+	if err := rc.saveTemplate(template, rc.store.UpdateTemplate); err != nil {
+		respondTemplateError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, HttpMessage{MESSAGE: name})
+}
+
+// DeleteTemplate godoc
+// @Summary Deletes a template
+// @Description Readmes already created from the template keep their elements, built in templates cannot be deleted
+// @Accept json
+// @Produce json
+// @Param	name	path	string	true	"template name"
+// @Success	200	{object}	HttpMessage	"returns the deleted template name"
+// @Failure 403	{object}	HttpErrorMessage	"built in templates cannot be deleted"
+// @Failure 404	{object}	HttpErrorMessage	"could not find template"
+// @Router	/template/{name}	[delete]
+func (rc *readmeController) deleteTemplate(c *gin.Context) {
+	name := c.Param("name")
+
+	if _, ok := builtinTemplates[name]; ok {
+		c.IndentedJSON(http.StatusForbidden, HttpErrorMessage{MESSAGE: "built in templates cannot be deleted"})
+		return
+	}
+
+	if err := rc.store.DeleteTemplate(name); err != nil {
+		respondTemplateError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, HttpMessage{MESSAGE: name})
+}
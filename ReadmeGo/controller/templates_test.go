@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltinTemplatesAreValid(t *testing.T) {
+	for name, template := range builtinTemplates {
+		require.Equal(t, name, template.NAME)
+		require.True(t, template.BUILTIN)
+		for _, element := range template.ELEMENTS {
+			require.NoError(t, validateElement(element), name)
+		}
+	}
+}
+
+func TestCreateReadmeFromTemplate(t *testing.T) {
+	store := newMemoryStore()
+	router := setupRouter(store)
+	w := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme?name=1&template=go-library", nil)
+	router.ServeHTTP(w, req1)
+
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	// the placeholders have to be filled in before the readme renders
+	r1 := httptest.NewRecorder()
+	req3, _ := http.NewRequest("GET", "/readme/1", nil)
+	router.ServeHTTP(r1, req3)
+
+	require.Equal(t, http.StatusUnprocessableEntity, r1.Code)
+	require.Equal(t, "{\n    \"message\": \"undefined variables: License, ModulePath, ProjectName\"\n}", r1.Body.String())
+
+	r2 := httptest.NewRecorder()
+	req4, _ := http.NewRequest("PUT", "/readme/1/variables", bytes.NewBufferString(`{"ProjectName": "readmego", "ModulePath": "example.com/readmego", "License": "Apache 2.0"}`))
+	router.ServeHTTP(r2, req4)
+	require.Equal(t, http.StatusOK, r2.Code)
+
+	rendered := getRenderedReadme(t, router, "1")
+	require.Len(t, rendered, len(builtinTemplates["go-library"].ELEMENTS))
+	require.Equal(t, "# readmego\n", rendered[0])
+	require.Equal(t, "```sh\ngo get example.com/readmego\n```\n", rendered[4])
+
+	// every readme gets its own element ids
+	r := httptest.NewRecorder()
+	req2, _ := http.NewRequest("POST", "/readme?name=2&template=go-library", nil)
+	router.ServeHTTP(r, req2)
+
+	first, err := store.Get("1")
+	require.NoError(t, err)
+	second, err := store.Get("2")
+	require.NoError(t, err)
+	require.NotEmpty(t, first.ELEMENTS[0].ID)
+	require.NotEqual(t, first.ELEMENTS[0].ID, second.ELEMENTS[0].ID)
+}
+
+func TestCreateReadmeReturnsTemplateNotFound(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("POST", "/readme?name=1&template=missing", nil)
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusNotFound, w.Code)
+	require.Equal(t, "{\n    \"message\": \"could not find template\"\n}", w.Body.String())
+}
+
+func TestTemplateCrud(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			router := setupRouter(store)
+			template := `{"name": "team", "description": "our skeleton", "elements": [
+				{"type": "header", "level": 1, "value": "Name"},
+				{"type": "paragraph", "value": "What it does"}
+			]}`
+
+			w := httptest.NewRecorder()
+			req1, _ := http.NewRequest("POST", "/template", bytes.NewBufferString(template))
+			router.ServeHTTP(w, req1)
+			require.Equal(t, http.StatusCreated, w.Code)
+
+			r := httptest.NewRecorder()
+			req2, _ := http.NewRequest("POST", "/template", bytes.NewBufferString(template))
+			router.ServeHTTP(r, req2)
+			require.Equal(t, http.StatusConflict, r.Code)
+
+			r = httptest.NewRecorder()
+			req3, _ := http.NewRequest("PUT", "/template/team", bytes.NewBufferString(`{"elements": [{"type": "header", "level": 2, "value": "Changed"}]}`))
+			router.ServeHTTP(r, req3)
+			require.Equal(t, http.StatusOK, r.Code)
+
+			r = httptest.NewRecorder()
+			req4, _ := http.NewRequest("GET", "/template/team", nil)
+			router.ServeHTTP(r, req4)
+			require.Equal(t, http.StatusOK, r.Code)
+
+			var stored Template
+			require.NoError(t, json.Unmarshal(r.Body.Bytes(), &stored))
+			require.Equal(t, Template{NAME: "team", ELEMENTS: []Element{{TYPE: HeaderElement, LEVEL: 2, VALUE: "Changed"}}}, stored)
+
+			r = httptest.NewRecorder()
+			req5, _ := http.NewRequest("POST", "/readme?name=1&template=team", nil)
+			router.ServeHTTP(r, req5)
+			require.Equal(t, http.StatusCreated, r.Code)
+			require.Equal(t, []string{"## Changed\n"}, getRenderedReadme(t, router, "1"))
+
+			r = httptest.NewRecorder()
+			req6, _ := http.NewRequest("GET", "/template", nil)
+			router.ServeHTTP(r, req6)
+
+			var templates []Template
+			require.NoError(t, json.Unmarshal(r.Body.Bytes(), &templates))
+			names := make([]string, len(templates))
+			for i, template := range templates {
+				names[i] = template.NAME
+			}
+			require.Equal(t, []string{"cli", "go-library", "service", "team"}, names)
+
+			r = httptest.NewRecorder()
+			req7, _ := http.NewRequest("DELETE", "/template/team", nil)
+			router.ServeHTTP(r, req7)
+			require.Equal(t, http.StatusOK, r.Code)
+
+			// readmes created from a template keep their elements
+			require.Equal(t, []string{"## Changed\n"}, getRenderedReadme(t, router, "1"))
+
+			for _, method := range []string{"GET", "PUT", "DELETE"} {
+				r = httptest.NewRecorder()
+				req, _ := http.NewRequest(method, "/template/team", bytes.NewBufferString(`{"elements": []}`))
+				router.ServeHTTP(r, req)
+				require.Equal(t, http.StatusNotFound, r.Code, method)
+			}
+		})
+	}
+}
+
+func TestBuiltinTemplatesCannotBeChanged(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+
+	for _, request := range []struct {
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{"POST", "/template", `{"name": "go-library", "elements": []}`, http.StatusConflict},
+		{"PUT", "/template/go-library", `{"elements": []}`, http.StatusForbidden},
+		{"DELETE", "/template/go-library", "", http.StatusForbidden},
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(request.method, request.path, bytes.NewBufferString(request.body))
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, request.status, w.Code, request.method)
+	}
+}
+
+func TestCreateTemplateReturnsInvalidElement(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("POST", "/template", bytes.NewBufferString(`{"name": "team", "elements": [{"type": "header", "level": 9, "value": "x"}]}`))
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	ErrNothingToUndo = errors.New("nothing to undo")
+	ErrNothingToRedo = errors.New("nothing to redo")
+)
+
+// undoLimit is how many operations are kept per readme, older ones can no longer be undone
+const undoLimit = 50
+
+// Operation is one change to the elements of a readme in the undo or redo log
+type Operation struct {
+	OPERATION string    `json:"operation"`
+	BEFORE    []Element `json:"before"`
+	AFTER     []Element `json:"after"`
+	// VARIABLES is only set when the operation changed the variables
+	VARIABLES *VariablesChange `json:"variables,omitempty"`
+}
+
+// VariablesChange is the variables of a readme before and after an operation, nil when it rendered as written
+type VariablesChange struct {
+	BEFORE map[string]string `json:"before"`
+	AFTER  map[string]string `json:"after"`
+}
+
+func cloneOperations(operations []Operation) []Operation {
+	if operations == nil {
+		return nil
+	}
+
+	cloned := make([]Operation, len(operations))
+	for i, operation := range operations {
+		operation.BEFORE = Readme{ELEMENTS: operation.BEFORE}.clone().ELEMENTS
+		operation.AFTER = Readme{ELEMENTS: operation.AFTER}.clone().ELEMENTS
+		if operation.VARIABLES != nil {
+			operation.VARIABLES = &VariablesChange{BEFORE: cloneVariables(operation.VARIABLES.BEFORE), AFTER: cloneVariables(operation.VARIABLES.AFTER)}
+		}
+		cloned[i] = operation
+	}
+
+	return cloned
+}
+
+// pushUndo logs a new operation, dropping the oldest past undoLimit. A new operation means
+// whatever was undone before can no longer be redone.
+func (r *Readme) pushUndo(operation Operation) {
+	r.UNDO = append(r.UNDO, operation)
+	if len(r.UNDO) > undoLimit {
+		r.UNDO = append([]Operation{}, r.UNDO[len(r.UNDO)-undoLimit:]...)
+	}
+	r.REDO = nil
+}
+
+// Undo godoc
+// @Summary Undoes the last change to a readme
+// @Description Puts the elements back to how they were before the most recent add, update, delete, move or rollback,
+// @Description or the variables before they were last set or removed.
+// @Description The undo is recorded as a new revision and can be redone.
+// @Accept json
+// @Produce json
+// @Param	id	path	string	true	"readme id"
+// @Param	X-Author	header	string	false	"who made the change"
+// @Success	200	{object}	Revision	"the revision created by the undo"
+// @Failure 404	{object}	HttpErrorMessage	"could not find readme"
+// @Failure 409	{object}	HttpErrorMessage	"nothing to undo"
+// @Router	/readme/{id}/undo	[post]
+func (rc *readmeController) undo(c *gin.Context) {
+	readmeId := c.Param("id")
+
+	revision, err := rc.change(c, readmeId, "undo", func(readme *Readme) error {
+		if len(readme.UNDO) == 0 {
+			return ErrNothingToUndo
+		}
+
+		operation := readme.UNDO[len(readme.UNDO)-1]
+		readme.UNDO = readme.UNDO[:len(readme.UNDO)-1]
+		readme.REDO = append(readme.REDO, operation)
+		readme.ELEMENTS = Readme{ELEMENTS: operation.BEFORE}.clone().ELEMENTS
+		if operation.VARIABLES != nil {
+			readme.VARIABLES = cloneVariables(operation.VARIABLES.BEFORE)
+		}
+		return nil
+	})
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, revision)
+}
+
+// Redo godoc
+// @Summary Redoes the last undone change to a readme
+// @Description Applies the most recently undone operation again, only possible until the readme is changed some other way.
+// @Description The redo is recorded as a new revision.
+// @Accept json
+// @Produce json
+// @Param	id	path	string	true	"readme id"
+// @Param	X-Author	header	string	false	"who made the change"
+// @Success	200	{object}	Revision	"the revision created by the redo"
+// @Failure 404	{object}	HttpErrorMessage	"could not find readme"
+// @Failure 409	{object}	HttpErrorMessage	"nothing to redo"
+// @Router	/readme/{id}/redo	[post]
+func (rc *readmeController) redo(c *gin.Context) {
+	readmeId := c.Param("id")
+
+	revision, err := rc.change(c, readmeId, "redo", func(readme *Readme) error {
+		if len(readme.REDO) == 0 {
+			return ErrNothingToRedo
+		}
+
+		operation := readme.REDO[len(readme.REDO)-1]
+		readme.REDO = readme.REDO[:len(readme.REDO)-1]
+		readme.UNDO = append(readme.UNDO, operation)
+		readme.ELEMENTS = Readme{ELEMENTS: operation.AFTER}.clone().ELEMENTS
+		if operation.VARIABLES != nil {
+			readme.VARIABLES = cloneVariables(operation.VARIABLES.AFTER)
+		}
+		return nil
+	})
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, revision)
+}
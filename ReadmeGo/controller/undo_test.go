@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUndoRedo(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			router := setupRouter(store)
+			w := httptest.NewRecorder()
+
+			req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+			router.ServeHTTP(w, req1)
+
+			first := addParagraph(t, router, "1", "first")
+			addParagraph(t, router, "1", "second")
+
+			req2, _ := http.NewRequest("PUT", "/readme/1/element/"+first, bytes.NewBufferString(`{"type": "paragraph", "value": "changed"}`))
+			router.ServeHTTP(w, req2)
+
+			req3, _ := http.NewRequest("POST", "/readme/1/element/"+first+"/move", bytes.NewBufferString(`{"index": 1}`))
+			router.ServeHTTP(w, req3)
+
+			require.Equal(t, []string{"second\n", "changed\n"}, getRenderedReadme(t, router, "1"))
+
+			for _, expected := range [][]string{{"changed\n", "second\n"}, {"first\n", "second\n"}, {"first\n"}} {
+				r := httptest.NewRecorder()
+				req, _ := http.NewRequest("POST", "/readme/1/undo", nil)
+				router.ServeHTTP(r, req)
+
+				require.Equal(t, http.StatusOK, r.Code)
+				require.Equal(t, expected, getRenderedReadme(t, router, "1"))
+			}
+
+			for _, expected := range [][]string{{"first\n", "second\n"}, {"changed\n", "second\n"}} {
+				r := httptest.NewRecorder()
+				req, _ := http.NewRequest("POST", "/readme/1/redo", nil)
+				router.ServeHTTP(r, req)
+
+				require.Equal(t, http.StatusOK, r.Code)
+				require.Equal(t, expected, getRenderedReadme(t, router, "1"))
+			}
+		})
+	}
+}
+
+func TestNewChangeClearsRedo(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+	r := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+	router.ServeHTTP(w, req1)
+
+	addParagraph(t, router, "1", "first")
+
+	req2, _ := http.NewRequest("POST", "/readme/1/undo", nil)
+	router.ServeHTTP(w, req2)
+
+	addParagraph(t, router, "1", "second")
+
+	req3, _ := http.NewRequest("POST", "/readme/1/redo", nil)
+	router.ServeHTTP(r, req3)
+
+	require.Equal(t, http.StatusConflict, r.Code)
+	require.JSONEq(t, `{"message": "nothing to redo"}`, r.Body.String())
+	require.Equal(t, []string{"second\n"}, getRenderedReadme(t, router, "1"))
+}
+
+func TestUndoReturnsNothingToUndo(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+	router.ServeHTTP(w, req1)
+
+	for path, code := range map[string]int{"/readme/1/undo": http.StatusConflict, "/readme/INVALID/undo": http.StatusNotFound} {
+		r := httptest.NewRecorder()
+		req2, _ := http.NewRequest("POST", path, nil)
+		router.ServeHTTP(r, req2)
+
+		require.Equal(t, code, r.Code, path)
+	}
+}
+
+func TestUndoLogIsBounded(t *testing.T) {
+	var readme Readme
+	for i := 0; i < undoLimit+10; i++ {
+		readme.pushUndo(Operation{OPERATION: "add paragraph", AFTER: []Element{{ID: string(rune('a' + i%26)), TYPE: ParagraphElement}}})
+	}
+
+	require.Len(t, readme.UNDO, undoLimit)
+	require.Equal(t, string(rune('a'+10%26)), readme.UNDO[0].AFTER[0].ID)
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/gin-gonic/gin"
+)
+
+var variableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// interpolationError is returned when a readme can't be rendered, because it uses variables that
+// aren't set or snippets that don't exist
+type interpolationError struct {
+	message string
+}
+
+func (e interpolationError) Error() string {
+	return e.message
+}
+
+type VariablesResponse struct {
+	VARIABLES map[string]string `json:"variables" binding:"required"`
+	// UNDEFINED lists the variables the elements use that have no value
+	UNDEFINED []string `json:"undefined" binding:"required"`
+}
+
+// textFields returns a pointer to every text of the element that can hold variables
+func textFields(element *Element) []*string {
+	fields := []*string{&element.VALUE, &element.DESCRIPTION, &element.LINK}
+	for i := range element.COLUMN_NAMES {
+		fields = append(fields, &element.COLUMN_NAMES[i])
+	}
+
+	for _, values := range element.COLUMN_VALUES {
+		for i := range values {
+			fields = append(fields, &values[i])
+		}
+	}
+
+	return append(fields, listTextFields(element.LIST)...)
+}
+
+// errNotPlaceholder is returned for template actions other than placeholders, anything more could make
+// rendering a readme run for as long or write as much as its elements ask
+var errNotPlaceholder = errors.New(`only {{.Name}} placeholders can be used, write {{"{{"}} for a literal {{`)
+
+// parseText parses the text as a template that can only fill in variables with {{.Name}}. A quoted
+// string like {{"{{"}} is written out as is, so text that looks like a template can be escaped.
+// Text without actions is left alone.
+func parseText(text string) (*template.Template, error) {
+	if !strings.Contains(text, "{{") {
+		return nil, nil
+	}
+
+	parsed, err := template.New("element").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	// define and block add templates next to the element
+	if len(parsed.Templates()) > 1 {
+		return nil, errNotPlaceholder
+	}
+
+	for _, node := range parsed.Tree.Root.Nodes {
+		if _, ok := node.(*parse.TextNode); ok {
+			continue
+		}
+		if _, ok := placeholder(node); !ok {
+			return nil, errNotPlaceholder
+		}
+	}
+
+	return parsed, nil
+}
+
+// placeholder returns the variable an action like {{.Name}} fills in, the name is empty for a
+// quoted string. ok is false for every other node.
+func placeholder(node parse.Node) (name string, ok bool) {
+	action, isAction := node.(*parse.ActionNode)
+	if !isAction || len(action.Pipe.Decl) > 0 || len(action.Pipe.Cmds) != 1 || len(action.Pipe.Cmds[0].Args) != 1 {
+		return "", false
+	}
+
+	switch arg := action.Pipe.Cmds[0].Args[0].(type) {
+	case *parse.FieldNode:
+		return arg.Ident[0], len(arg.Ident) == 1
+	case *parse.StringNode:
+		return "", true
+	}
+
+	return "", false
+}
+
+// usedVariables adds the variables the placeholders of the parsed text refer to, like .ProjectName, to used
+func usedVariables(parsed *template.Template, used map[string]bool) {
+	for _, node := range parsed.Tree.Root.Nodes {
+		if name, ok := placeholder(node); ok && name != "" {
+			used[name] = true
+		}
+	}
+}
+
+// elementName names the element in errors by its id, or by its index when it has none yet
+func elementName(elements []Element, i int) string {
+	if elements[i].ID != "" {
+		return "element " + elements[i].ID
+	}
+
+	return "element " + strconv.Itoa(i)
+}
+
+// undefinedVariables returns the variables the elements use that aren't set, sorted by name
+func undefinedVariables(elements []Element, variables map[string]string) ([]string, error) {
+	used := make(map[string]bool)
+	for i := range elements {
+		for _, field := range textFields(&elements[i]) {
+			parsed, err := parseText(*field)
+			if err != nil {
+				// the text/template message points into its own parser, not at what to write instead
+				return nil, interpolationError{elementName(elements, i) + " is not a valid template: " + errNotPlaceholder.Error()}
+			}
+			if parsed != nil {
+				usedVariables(parsed, used)
+			}
+		}
+	}
+
+	undefined := []string{}
+	for name := range used {
+		if _, ok := variables[name]; !ok {
+			undefined = append(undefined, name)
+		}
+	}
+
+	sort.Strings(undefined)
+	return undefined, nil
+}
+
+// interpolateReadme fills the variables into the {{.Name}} placeholders of the elements. Readmes without
+// variables render as written, once a readme has variables every element can only use placeholders of them.
+func interpolateReadme(readme Readme) (Readme, error) {
+	if readme.VARIABLES == nil {
+		return readme, nil
+	}
+
+	undefined, err := undefinedVariables(readme.ELEMENTS, readme.VARIABLES)
+	if err != nil {
+		return readme, err
+	}
+
+	if len(undefined) > 0 {
+		return readme, interpolationError{"undefined variables: " + strings.Join(undefined, ", ")}
+	}
+
+	interpolated := readme.clone()
+	for i := range interpolated.ELEMENTS {
+		element := &interpolated.ELEMENTS[i]
+		// column values are keyed by the column name so they are looked up before the names change
+		names := append([]string{}, element.COLUMN_NAMES...)
+
+		for _, field := range textFields(element) {
+			parsed, err := parseText(*field)
+			if err != nil || parsed == nil {
+				continue
+			}
+
+			var text strings.Builder
+			if err := parsed.Execute(&text, readme.VARIABLES); err != nil {
+				return readme, interpolationError{elementName(interpolated.ELEMENTS, i) + " could not be rendered: " + err.Error()}
+			}
+			*field = text.String()
+		}
+
+		if element.COLUMN_VALUES != nil {
+			columnValues := make(map[string][]string, len(element.COLUMN_VALUES))
+			for column, name := range names {
+				if values, ok := element.COLUMN_VALUES[name]; ok {
+					columnValues[element.COLUMN_NAMES[column]] = values
+				}
+			}
+			element.COLUMN_VALUES = columnValues
+		}
+	}
+
+	return interpolated, nil
+}
+
+// validateVariables checks every variable name can be used as {{.Name}}
+func validateVariables(variables map[string]string) error {
+	for name := range variables {
+		if !variableNamePattern.MatchString(name) {
+			return invalidRequestError{"variable " + strconv.Quote(name) + " should be a letter or underscore followed by letters, digits or underscores"}
+		}
+	}
+
+	return nil
+}
+
+// respondVariables writes the variables of the readme with the ones its elements still miss
+func respondVariables(c *gin.Context, readme Readme) {
+	undefined, err := undefinedVariables(readme.ELEMENTS, readme.VARIABLES)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	variables := readme.VARIABLES
+	if variables == nil {
+		variables = map[string]string{}
+	}
+
+	c.IndentedJSON(http.StatusOK, VariablesResponse{VARIABLES: variables, UNDEFINED: undefined})
+}
+
+// GetVariables godoc
+// @Summary Returns the variables of a readme
+// @Description Returns the values filled into {{.Name}} placeholders when the readme is rendered and the placeholders that have no value yet
+// @Accept json
+// @Produce json
+// @Param	id	path	string	true	"readme id"
+// @Success	200	{object}	VariablesResponse	"the variables"
+// @Failure 404	{object}	HttpErrorMessage	"could not find readme"
+// @Failure 422	{object}	HttpErrorMessage	"an element is not a valid template"
+// @Router	/readme/{id}/variables	[get]
+func (rc *readmeController) getVariables(c *gin.Context) {
+	readme, err := rc.store.Get(c.Param("id"))
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	respondVariables(c, readme)
+}
+
+// SetVariables godoc
+// @Summary Sets the variables of a readme
+// @Description Replaces the variables filled into the {{.Name}} placeholders of the elements when the readme is rendered.
+// @Description Once a readme has variables, rendering it fails while an element uses a variable that isn't set
+// @Description or any template action other than a placeholder, write {{"{{"}} for a literal {{.
+// @Accept json
+// @Produce json
+// @Param	id	path	string	true	"readme id"
+// @Param	variables	body	map[string]string	true	"variable names and their values"
+// @Param	X-Author	header	string	false	"who made the change"
+// @Success	200	{object}	VariablesResponse	"the variables"
+// @Failure 400	{object}	HttpErrorMessage	"incorrect request body"
+// @Failure 404	{object}	HttpErrorMessage	"could not find readme"
+// @Failure 422	{object}	HttpErrorMessage	"an element is not a valid template, nothing was saved"
+// @Router	/readme/{id}/variables	[put]
+func (rc *readmeController) setVariables(c *gin.Context) {
+	var variables map[string]string
+
+	if err := c.BindJSON(&variables); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, HttpErrorMessage{MESSAGE: "incorrect request body, should be an object of variable names to values"})
+		return
+	}
+
+	if err := validateVariables(variables); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	if variables == nil {
+		variables = map[string]string{}
+	}
+
+	var undefined []string
+	_, err := rc.update(c, c.Param("id"), "set variables", func(readme *Readme) error {
+		// the readme has to render with the variables, otherwise nothing is saved
+		var err error
+		if undefined, err = undefinedVariables(readme.ELEMENTS, variables); err != nil {
+			return err
+		}
+
+		readme.VARIABLES = variables
+		return nil
+	})
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, VariablesResponse{VARIABLES: variables, UNDEFINED: undefined})
+}
+
+// DeleteVariables godoc
+// @Summary Removes the variables of a readme
+// @Description The readme renders as written again, placeholders are left in the markdown
+// @Accept json
+// @Produce json
+// @Param	id	path	string	true	"readme id"
+// @Param	X-Author	header	string	false	"who made the change"
+// @Success	200	{object}	HttpMessage	"returns the readme id"
+// @Failure 404	{object}	HttpErrorMessage	"could not find readme"
+// @Router	/readme/{id}/variables	[delete]
+func (rc *readmeController) deleteVariables(c *gin.Context) {
+	readmeId := c.Param("id")
+
+	_, err := rc.update(c, readmeId, "delete variables", func(readme *Readme) error {
+		readme.VARIABLES = nil
+		return nil
+	})
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, HttpMessage{MESSAGE: readmeId})
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterpolateReadme(t *testing.T) {
+	readme := Readme{
+		ELEMENTS: []Element{
+			{TYPE: HeaderElement, LEVEL: 1, VALUE: "{{.ProjectName}}"},
+			{TYPE: LinkElement, DESCRIPTION: "docs", LINK: "https://pkg.go.dev/{{.ModulePath}}"},
+			{TYPE: TableElement, COLUMN_NAMES: []string{"{{.ProjectName}}", "b"}, COLUMN_VALUES: map[string][]string{
+				"{{.ProjectName}}": {"{{.ModulePath}}"},
+				"b":                {"{{ .Stability }}"},
+			}},
+			{TYPE: CodeElement, CODE_LANGUAGE: "go", VALUE: `t.Execute(w, "{{"{{"}}.Name}}")`},
+		},
+		VARIABLES: map[string]string{"ProjectName": "readmego", "ModulePath": "example.com/readmego", "Stability": "stable"},
+	}
+
+	interpolated, err := interpolateReadme(readme)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"# readmego\n",
+		"[docs](https://pkg.go.dev/example.com/readmego)\n",
+		"|readmego|b|\n| --- | --- |\n|example.com/readmego|stable|\n",
+		"```go\nt.Execute(w, \"{{.Name}}\")\n```\n",
+	}, renderElements(interpolated.ELEMENTS))
+
+	// the stored elements keep their placeholders
+	require.Equal(t, "{{.ProjectName}}", readme.ELEMENTS[0].VALUE)
+}
+
+func TestInterpolateReadmeWithoutVariablesRendersAsWritten(t *testing.T) {
+	readme := Readme{ELEMENTS: []Element{{TYPE: RawElement, VALUE: "{{ not a template\n"}}}
+
+	interpolated, err := interpolateReadme(readme)
+	require.NoError(t, err)
+	require.Equal(t, readme, interpolated)
+}
+
+func TestInterpolateReadmeListsUndefinedVariables(t *testing.T) {
+	readme := Readme{
+		ELEMENTS: []Element{
+			{TYPE: ParagraphElement, VALUE: "{{.Owner}}/{{.Repo}} {{.Tag}} {{.Owner}}"},
+			{TYPE: ParagraphElement, VALUE: "{{.ProjectName}}"},
+		},
+		VARIABLES: map[string]string{"ProjectName": "readmego"},
+	}
+
+	_, err := interpolateReadme(readme)
+	require.Equal(t, interpolationError{"undefined variables: Owner, Repo, Tag"}, err)
+
+	readme.ELEMENTS = []Element{{TYPE: ParagraphElement, VALUE: "{{.ProjectName"}}
+	_, err = interpolateReadme(readme)
+	require.ErrorAs(t, err, &interpolationError{})
+}
+
+func TestInterpolateReadmeOnlyFillsInPlaceholders(t *testing.T) {
+	for _, value := range []string{
+		"{{range 1000000000}}xxxxxxxx{{end}}",
+		"{{with .Tag}}{{.}}{{end}}",
+		"{{if .Beta}}beta{{end}}",
+		`{{define "x"}}{{.Name}}{{end}}`,
+		`{{template "element" .}}`,
+		`{{printf "%s" .Name}}`,
+		"{{$name := .Name}}",
+		"{{.Name.Length}}",
+		"{{ .Values.image.tag }}",
+	} {
+		readme := Readme{ELEMENTS: []Element{{TYPE: ParagraphElement, VALUE: value}}, VARIABLES: map[string]string{"Name": "readmego"}}
+
+		_, err := interpolateReadme(readme)
+		require.Equal(t, interpolationError{"element 0 is not a valid template: " + errNotPlaceholder.Error()}, err, value)
+	}
+}
+
+func TestReadmeVariables(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			router := setupRouter(store)
+			w := httptest.NewRecorder()
+
+			req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+			router.ServeHTTP(w, req1)
+			addParagraph(t, router, "1", "{{.ProjectName}} by {{.Owner}}")
+
+			// a readme without variables renders as written
+			require.Equal(t, []string{"{{.ProjectName}} by {{.Owner}}\n"}, getRenderedReadme(t, router, "1"))
+
+			r := httptest.NewRecorder()
+			req2, _ := http.NewRequest("PUT", "/readme/1/variables", bytes.NewBufferString(`{"ProjectName": "readmego"}`))
+			router.ServeHTTP(r, req2)
+			require.Equal(t, http.StatusOK, r.Code)
+
+			var variables VariablesResponse
+			require.NoError(t, json.Unmarshal(r.Body.Bytes(), &variables))
+			require.Equal(t, VariablesResponse{VARIABLES: map[string]string{"ProjectName": "readmego"}, UNDEFINED: []string{"Owner"}}, variables)
+
+			for _, path := range []string{"/readme/1", "/readme/1?format=markdown", "/readme/1/file", "/readme/1/html"} {
+				r = httptest.NewRecorder()
+				req, _ := http.NewRequest("GET", path, nil)
+				router.ServeHTTP(r, req)
+
+				require.Equal(t, http.StatusUnprocessableEntity, r.Code, path)
+				require.Contains(t, r.Body.String(), "undefined variables: Owner", path)
+			}
+
+			r = httptest.NewRecorder()
+			req3, _ := http.NewRequest("PUT", "/readme/1/variables", bytes.NewBufferString(`{"ProjectName": "readmego", "Owner": "Matthew"}`))
+			router.ServeHTTP(r, req3)
+			require.Equal(t, http.StatusOK, r.Code)
+
+			require.Equal(t, []string{"readmego by Matthew\n"}, getRenderedReadme(t, router, "1"))
+
+			r = httptest.NewRecorder()
+			req4, _ := http.NewRequest("GET", "/readme/1/file", nil)
+			router.ServeHTTP(r, req4)
+			require.Equal(t, "readmego by Matthew\n", r.Body.String())
+
+			// the elements are returned as written so they can be edited
+			r = httptest.NewRecorder()
+			req5, _ := http.NewRequest("GET", "/readme/1?format=elements", nil)
+			router.ServeHTTP(r, req5)
+
+			var readme Readme
+			require.NoError(t, json.Unmarshal(r.Body.Bytes(), &readme))
+			require.Equal(t, "{{.ProjectName}} by {{.Owner}}", readme.ELEMENTS[0].VALUE)
+			require.Equal(t, map[string]string{"ProjectName": "readmego", "Owner": "Matthew"}, readme.VARIABLES)
+
+			r = httptest.NewRecorder()
+			req6, _ := http.NewRequest("DELETE", "/readme/1/variables", nil)
+			router.ServeHTTP(r, req6)
+			require.Equal(t, http.StatusOK, r.Code)
+
+			require.Equal(t, []string{"{{.ProjectName}} by {{.Owner}}\n"}, getRenderedReadme(t, router, "1"))
+		})
+	}
+}
+
+func TestVariablesChangesAreRecordedAndCanBeUndone(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			router := setupRouter(store)
+			server := httptest.NewServer(router)
+			defer server.Close()
+
+			w := httptest.NewRecorder()
+			req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+			router.ServeHTTP(w, req1)
+			addParagraph(t, router, "1", "{{.ProjectName}}")
+
+			response, err := http.Get(server.URL + "/readme/1/events")
+			require.NoError(t, err)
+			defer response.Body.Close()
+			stream := bufio.NewReader(response.Body)
+
+			for _, variables := range []string{`{"ProjectName": "readmego"}`, `{"ProjectName": "renamed"}`} {
+				req, _ := http.NewRequest("PUT", "/readme/1/variables", bytes.NewBufferString(variables))
+				req.Header.Set("X-Author", "matthew")
+				router.ServeHTTP(w, req)
+
+				change, event := readEvent(t, stream)
+				require.Equal(t, "variables", change)
+				require.Equal(t, "set variables", event.OPERATION)
+			}
+			require.Equal(t, []string{"renamed\n"}, getRenderedReadme(t, router, "1"))
+
+			r := httptest.NewRecorder()
+			req2, _ := http.NewRequest("GET", "/readme/1/revisions", nil)
+			router.ServeHTTP(r, req2)
+
+			var revisions []Revision
+			require.NoError(t, json.Unmarshal(r.Body.Bytes(), &revisions))
+			require.Equal(t, "set variables", revisions[len(revisions)-1].OPERATION)
+			require.Equal(t, "matthew", revisions[len(revisions)-1].AUTHOR)
+
+			req3, _ := http.NewRequest("POST", "/readme/1/undo", nil)
+			router.ServeHTTP(w, req3)
+			require.Equal(t, []string{"readmego\n"}, getRenderedReadme(t, router, "1"))
+
+			req4, _ := http.NewRequest("POST", "/readme/1/undo", nil)
+			router.ServeHTTP(w, req4)
+			require.Equal(t, []string{"{{.ProjectName}}\n"}, getRenderedReadme(t, router, "1"))
+
+			req5, _ := http.NewRequest("POST", "/readme/1/redo", nil)
+			router.ServeHTTP(w, req5)
+			require.Equal(t, []string{"readmego\n"}, getRenderedReadme(t, router, "1"))
+		})
+	}
+}
+
+func TestSetVariablesReturnsInvalidName(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+	router.ServeHTTP(w, req1)
+
+	r := httptest.NewRecorder()
+	req2, _ := http.NewRequest("PUT", "/readme/1/variables", bytes.NewBufferString(`{"project-name": "readmego"}`))
+	router.ServeHTTP(r, req2)
+
+	require.Equal(t, http.StatusBadRequest, r.Code)
+}
+
+func TestSetVariablesReturnsReadmeNotFound(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("PUT", "/readme/missing/variables", bytes.NewBufferString(`{}`))
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestSetVariablesSavesNothingWhenAnElementIsNotAValidTemplate(t *testing.T) {
+	router := setupRouter(newMemoryStore())
+	w := httptest.NewRecorder()
+
+	req1, _ := http.NewRequest("POST", "/readme?name=1", nil)
+	router.ServeHTTP(w, req1)
+	braces := addParagraph(t, router, "1", "uses {{ }} braces")
+
+	r := httptest.NewRecorder()
+	req2, _ := http.NewRequest("PUT", "/readme/1/variables", bytes.NewBufferString(`{"ProjectName": "readmego"}`))
+	router.ServeHTTP(r, req2)
+
+	require.Equal(t, http.StatusUnprocessableEntity, r.Code)
+	require.JSONEq(t, `{"message": "element `+braces+` is not a valid template: only {{.Name}} placeholders can be used, write {{\"{{\"}} for a literal {{"}`, r.Body.String())
+
+	r = httptest.NewRecorder()
+	req3, _ := http.NewRequest("GET", "/readme/1/revisions", nil)
+	router.ServeHTTP(r, req3)
+
+	var revisions []Revision
+	require.NoError(t, json.Unmarshal(r.Body.Bytes(), &revisions))
+	require.Len(t, revisions, 2)
+	require.Equal(t, []string{"uses {{ }} braces\n"}, getRenderedReadme(t, router, "1"))
+
+	// the undo log only holds adding the paragraph
+	r = httptest.NewRecorder()
+	req4, _ := http.NewRequest("POST", "/readme/1/undo", nil)
+	router.ServeHTTP(r, req4)
+	require.Equal(t, []string{}, getRenderedReadme(t, router, "1"))
+}
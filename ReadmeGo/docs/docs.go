@@ -24,6 +24,62 @@ const docTemplate = `{
     "basePath": "{{.BasePath}}",
     "paths": {
         "/readme": {
+            "get": {
+                "description": "Lists readmes ordered by name, a page at a time",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Lists readmes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "only readmes whose name starts with prefix",
+                        "name": "prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only readmes created after this RFC 3339 time",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only readmes created before this RFC 3339 time",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page to return, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "readmes per page, between 1 and 100, defaults to 20",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "a page of readmes",
+                        "schema": {
+                            "$ref": "#/definitions/main.ReadmeListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "incorrect query params",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    }
+                }
+            },
             "post": {
                 "description": "Creates a readme object can now add markdown elements",
                 "consumes": [
@@ -39,6 +95,18 @@ const docTemplate = `{
                         "description": "pass a value to create a user defined readmeId",
                         "name": "name",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "start from the placeholder elements of this template",
+                        "name": "template",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "who made the change",
+                        "name": "X-Author",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -48,6 +116,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/main.HttpMessage"
                         }
                     },
+                    "404": {
+                        "description": "could not find template",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    },
                     "409": {
                         "description": "Readme already exists",
                         "schema": {
@@ -57,32 +131,58 @@ const docTemplate = `{
                 }
             }
         },
-        "/readme/{id}": {
+        "/readme/archive": {
             "get": {
+                "description": "Renders every readme named by id, or every readme whose name starts with prefix, into a zip or tar.gz archive.\nEach readme is a markdown file named after it. The server has no image uploads, images are only links in the\nmarkdown, so there are no image files to bundle. Readmes with relative image links are refused with the links\nlisted unless allow_relative_images=true.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/zip",
+                    "application/gzip"
                 ],
-                "summary": "Returns a readme",
+                "summary": "Downloads many readmes as one archive",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "readme id",
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "readme ids, repeat for more than one",
                         "name": "id",
-                        "in": "path",
-                        "required": true
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "archive every readme whose name starts with prefix",
+                        "name": "prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "zip (default) or tar.gz",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "archive readmes whose relative image links will point at nothing",
+                        "name": "allow_relative_images",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "list of markdown strings",
+                        "description": "the archive",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "string"
-                            }
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "incorrect query",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
                         }
                     },
                     "404": {
@@ -90,51 +190,76 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/main.HttpErrorMessage"
                         }
+                    },
+                    "422": {
+                        "description": "the relative image links that are not in the archive",
+                        "schema": {
+                            "$ref": "#/definitions/main.RelativeImagesResponse"
+                        }
                     }
                 }
             }
         },
-        "/readme/{id}/blockquote": {
-            "put": {
-                "description": "creates a blockquote markdown string",
+        "/readme/import": {
+            "post": {
+                "description": "Parses the markdown into headers, paragraphs, code, blockquotes, links, images and tables that can be edited\none at a time. Anything else, like lists or html, is kept as raw markdown. The markdown is the request body,\nor the file field of a multipart form. Rendering the readme gives back a document with the same html, blocks\nthat wouldn't render the same and blank lines are kept raw. normalize=true makes every block the elements\ncan express typed instead, at the cost of changing it, and turns blank lines between blocks into one.",
                 "consumes": [
-                    "application/json"
+                    "text/markdown",
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Add Blockquote",
+                "summary": "Creates a readme from a markdown file",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "readme id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "pass a value to create a user defined readmeId",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "description": "the markdown document",
+                        "name": "markdown",
+                        "in": "body",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "type": "file",
+                        "description": "the markdown file",
+                        "name": "file",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "prefer typed elements over keeping the markdown as it is",
+                        "name": "normalize",
+                        "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "string for paragraph markdown",
-                        "name": "paragraph",
-                        "in": "query",
-                        "required": true
+                        "description": "who made the change",
+                        "name": "X-Author",
+                        "in": "header"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "returns created markdown blockquote string",
+                    "201": {
+                        "description": "returns a message with the readmeId",
                         "schema": {
                             "$ref": "#/definitions/main.HttpMessage"
                         }
                     },
                     "400": {
-                        "description": "blockquote can not be empty",
+                        "description": "could not read the markdown",
                         "schema": {
                             "$ref": "#/definitions/main.HttpErrorMessage"
                         }
                     },
-                    "404": {
-                        "description": "could not find readme",
+                    "409": {
+                        "description": "Readme already exists",
                         "schema": {
                             "$ref": "#/definitions/main.HttpErrorMessage"
                         }
@@ -142,49 +267,55 @@ const docTemplate = `{
                 }
             }
         },
-        "/readme/{id}/code": {
-            "put": {
-                "description": "creates a string in markdown code block with the language specified",
+        "/readme/spec": {
+            "post": {
+                "description": "Creates a whole readme in one call from a JSON or YAML document listing its elements in order.\nThe document has to match the JSON Schema served at /readme/spec/schema.",
                 "consumes": [
-                    "application/json"
+                    "application/json",
+                    "application/yaml"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Adds code to readme",
+                "summary": "Creates a readme from a spec",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "readme id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "request for code markdown",
-                        "name": "codeRequest",
+                        "description": "the readme spec",
+                        "name": "spec",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/main.AddCodeRequest"
+                            "$ref": "#/definitions/main.ReadmeSpec"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "who made the change",
+                        "name": "X-Author",
+                        "in": "header"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "returns the created code markdown string",
+                    "201": {
+                        "description": "the readme id and its markdown",
                         "schema": {
-                            "$ref": "#/definitions/main.HttpMessage"
+                            "$ref": "#/definitions/main.SpecResponse"
                         }
                     },
                     "400": {
-                        "description": "the code language is not suppored",
+                        "description": "spec does not match the schema",
                         "schema": {
                             "$ref": "#/definitions/main.HttpErrorMessage"
                         }
                     },
-                    "404": {
-                        "description": "could not find readme",
+                    "409": {
+                        "description": "Readme already exists",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    },
+                    "422": {
+                        "description": "elements use variables the spec doesn't set or snippets that don't exist",
                         "schema": {
                             "$ref": "#/definitions/main.HttpErrorMessage"
                         }
@@ -192,42 +323,33 @@ const docTemplate = `{
                 }
             }
         },
-        "/readme/{id}/file": {
-            "post": {
-                "description": "From all of your previous operations takes the readme and generates the markdown file",
-                "consumes": [
-                    "application/json"
-                ],
+        "/readme/spec/schema": {
+            "get": {
+                "description": "The JSON Schema every spec posted to /readme/spec is checked against",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Creates markdown file",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "readme id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
+                "summary": "Returns the readme spec JSON Schema",
                 "responses": {
                     "200": {
-                        "description": ""
+                        "description": "the JSON Schema",
+                        "schema": {
+                            "type": "object"
+                        }
                     }
                 }
             }
         },
-        "/readme/{id}/header": {
-            "put": {
-                "description": "Creates a string to be used for a markdown header",
+        "/readme/{id}": {
+            "get": {
+                "description": "By default returns the markdown string of every element, format=elements returns the typed elements and format=markdown the rendered document",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Adds Header",
+                "summary": "Returns a readme",
                 "parameters": [
                     {
                         "type": "string",
@@ -237,24 +359,24 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "request body for header",
-                        "name": "addHeader",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/main.AddHeaderRequest"
-                        }
+                        "type": "string",
+                        "description": "elements or markdown",
+                        "name": "format",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "returns the header markdown string",
+                        "description": "list of markdown strings",
                         "schema": {
-                            "$ref": "#/definitions/main.HttpMessage"
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
                         }
                     },
                     "400": {
-                        "description": "incorrect request body",
+                        "description": "unknown format",
                         "schema": {
                             "$ref": "#/definitions/main.HttpErrorMessage"
                         }
@@ -264,20 +386,23 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/main.HttpErrorMessage"
                         }
+                    },
+                    "422": {
+                        "description": "the elements use undefined variables",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
                     }
                 }
-            }
-        },
-        "/readme/{id}/image": {
-            "put": {
-                "description": "creates a markdown image string",
+            },
+            "delete": {
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Add Image",
+                "summary": "Deletes a readme",
                 "parameters": [
                     {
                         "type": "string",
@@ -285,30 +410,15 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "request body for adding image",
-                        "name": "addLinkRequest",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/main.AddLinkRequest"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "returns created markdown image link",
+                        "description": "returns the deleted readmeId",
                         "schema": {
                             "$ref": "#/definitions/main.HttpMessage"
                         }
                     },
-                    "400": {
-                        "description": "incorrect request body",
-                        "schema": {
-                            "$ref": "#/definitions/main.HttpErrorMessage"
-                        }
-                    },
                     "404": {
                         "description": "could not find readme",
                         "schema": {
@@ -318,16 +428,16 @@ const docTemplate = `{
                 }
             }
         },
-        "/readme/{id}/link": {
-            "put": {
-                "description": "creates a markdown link string",
+        "/readme/{id}/batch": {
+            "post": {
+                "description": "Applies the operations in order as one change: either every operation succeeds or the readme is left as it was.\nOperations are add_header, add_code, add_link, add_image, add_table, add_list and add_tasklist, taking the same body as their endpoint\nin the matching field, add_paragraph and add_blockquote, taking the text in paragraph or blockquote, update, taking element_id\nand the new element in element, delete and move, taking element_id, and toggle, taking element_id, item_id and optionally checked.\nLater operations see the elements added by earlier ones.\nThe whole batch is a single revision and is undone in one step.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Add Link",
+                "summary": "Applies several element operations at once",
                 "parameters": [
                     {
                         "type": "string",
@@ -337,47 +447,53 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "request for adding link",
-                        "name": "addLinkRequest",
+                        "description": "the operations in order",
+                        "name": "batchRequest",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/main.AddLinkRequest"
+                            "$ref": "#/definitions/main.BatchRequest"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "who made the change",
+                        "name": "X-Author",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "returns created markdown link",
+                        "description": "the revision and the result of every operation",
                         "schema": {
-                            "$ref": "#/definitions/main.HttpMessage"
+                            "$ref": "#/definitions/main.BatchResponse"
                         }
                     },
                     "400": {
-                        "description": "incorrect request body",
+                        "description": "an operation was invalid, nothing was applied",
                         "schema": {
-                            "$ref": "#/definitions/main.HttpErrorMessage"
+                            "$ref": "#/definitions/main.BatchResponse"
                         }
                     },
                     "404": {
-                        "description": "could not find readme",
+                        "description": "could not find readme or an element, nothing was applied",
                         "schema": {
-                            "$ref": "#/definitions/main.HttpErrorMessage"
+                            "$ref": "#/definitions/main.BatchResponse"
                         }
                     }
                 }
             }
         },
-        "/readme/{id}/paragraph": {
+        "/readme/{id}/blockquote": {
             "put": {
-                "description": "Updates readme to have a paragraph",
+                "description": "creates a blockquote markdown string",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Adds a paragraph",
+                "summary": "Add Blockquote",
                 "parameters": [
                     {
                         "type": "string",
@@ -388,21 +504,119 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "paragraph you want to add to the readme",
+                        "description": "string for paragraph markdown",
                         "name": "paragraph",
                         "in": "query",
                         "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "returns an paragraph markdown string",
-                        "schema": {
-                            "$ref": "#/definitions/main.HttpMessage"
-                        }
                     },
-                    "400": {
-                        "description": "paragraph param cannot be empty",
+                    {
+                        "type": "integer",
+                        "description": "insert the element at this index",
+                        "name": "index",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "insert the element before this element id",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "insert the element after this element id",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "who made the change",
+                        "name": "X-Author",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "returns created markdown blockquote string",
+                        "schema": {
+                            "$ref": "#/definitions/main.ElementResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "blockquote can not be empty",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "could not find readme",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/readme/{id}/code": {
+            "put": {
+                "description": "creates a string in markdown code block with the language specified",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Adds code to readme",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "readme id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "request for code markdown",
+                        "name": "codeRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.AddCodeRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "insert the element at this index",
+                        "name": "index",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "insert the element before this element id",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "insert the element after this element id",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "who made the change",
+                        "name": "X-Author",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "returns the created code markdown string",
+                        "schema": {
+                            "$ref": "#/definitions/main.ElementResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "the code language is not suppored",
                         "schema": {
                             "$ref": "#/definitions/main.HttpErrorMessage"
                         }
@@ -416,148 +630,2674 @@ const docTemplate = `{
                 }
             }
         },
-        "/readme/{id}/table": {
-            "put": {
-                "description": "creates a markdown table as a string",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "summary": "Add Table",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "readme id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "request table body",
-                        "name": "addTableRequest",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/main.AddTableRequest"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "returns table markdown string with values inserted",
-                        "schema": {
-                            "$ref": "#/definitions/main.HttpMessage"
-                        }
-                    },
-                    "400": {
-                        "description": "incorrect request body",
-                        "schema": {
-                            "$ref": "#/definitions/main.HttpErrorMessage"
-                        }
-                    },
-                    "404": {
-                        "description": "could not find readme",
-                        "schema": {
-                            "$ref": "#/definitions/main.HttpErrorMessage"
-                        }
+        "/readme/{id}/diff": {
+            "get": {
+                "description": "Compares the readme at revision from with the readme named by against (the same readme by default) at revision to.\nLeaving out a revision uses the current readme. Returns a unified diff of the markdown and the element changes.\nBoth are of the stored elements: snippets show as their placeholder comment and variables as {{.Name}}, so\nthe diff of a revision doesn't depend on the snippets and variables of today.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Diffs two versions of readmes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "readme id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "revision of the readme to compare from",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "readme id to compare to",
+                        "name": "against",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "revision of the against readme to compare to",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "unified and structural diff",
+                        "schema": {
+                            "$ref": "#/definitions/main.DiffResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "revision should be a number",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "could not find readme or revision",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/readme/{id}/element/{elementId}": {
+            "get": {
+                "description": "Returns the typed element so it can be edited and sent back to the update endpoint",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Returns an element",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "readme id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "element id",
+                        "name": "elementId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "the element",
+                        "schema": {
+                            "$ref": "#/definitions/main.Element"
+                        }
+                    },
+                    "404": {
+                        "description": "could not find readme or element",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replaces the element keeping its id and position, the type may change",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Replaces an element",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "readme id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "element id",
+                        "name": "elementId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "the new element",
+                        "name": "element",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.Element"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "who made the change",
+                        "name": "X-Author",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "returns the element markdown string",
+                        "schema": {
+                            "$ref": "#/definitions/main.ElementResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "incorrect request body",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "could not find readme or element",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    },
+                    "422": {
+                        "description": "the element includes a snippet that doesn't exist",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Deletes an element",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "readme id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "element id",
+                        "name": "elementId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "who made the change",
+                        "name": "X-Author",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "returns the deleted element id",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "could not find readme or element",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/readme/{id}/element/{elementId}/item/{itemId}/toggle": {
+            "post": {
+                "description": "Flips the checked state of a task list item. With checked set the item is set to it instead,\nso repeating the call doesn't undo it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Ticks off a task",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "readme id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "task list element id",
+                        "name": "elementId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "task id",
+                        "name": "itemId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "set the task to this state instead of flipping it",
+                        "name": "checked",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "who made the change",
+                        "name": "X-Author",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "the new state of the task and the task list markdown",
+                        "schema": {
+                            "$ref": "#/definitions/main.TaskItemResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "the element is not a task list",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "could not find readme, element or item",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/readme/{id}/element/{elementId}/move": {
+            "post": {
+                "description": "Moves the element before or after another element, or to an index. Exactly one of before, after or index should be set",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Moves an element",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "readme id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "element id",
+                        "name": "elementId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "where to move the element",
+                        "name": "moveElementRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.MoveElementRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "who made the change",
+                        "name": "X-Author",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "element ids in their new order",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "incorrect request body",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "could not find readme or element",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/readme/{id}/events": {
+            "get": {
+                "description": "Server-sent events, one for every element added, removed, modified or moved and one when the variables change.\nThe event name is the change and the data a ReadmeEvent with the rendered markdown of the element.\nThe stream ends when the readme is deleted.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "summary": "Streams changes to a readme",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "readme id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "stream of events",
+                        "schema": {
+                            "$ref": "#/definitions/main.ReadmeEvent"
+                        }
+                    },
+                    "404": {
+                        "description": "could not find readme",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/readme/{id}/file": {
+            "get": {
+                "description": "From all of your previous operations takes the readme and streams back the markdown file, named after the readme",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/markdown"
+                ],
+                "summary": "Downloads the markdown file",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "readme id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "the markdown file",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "could not find readme",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    },
+                    "422": {
+                        "description": "the elements use undefined variables",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Renders the readme and writes it to path inside the export directory the server was started with.\nThe path defaults to the readme name with a .md extension, paths leaving the export directory are refused.\nWithout an export directory nothing is written and the markdown file is streamed back like GET does.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/markdown"
+                ],
+                "summary": "Exports the markdown file",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "readme id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "where to write the file",
+                        "name": "export",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/main.ExportRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "the path that was written, or the markdown file when exporting is off",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpMessage"
+                        }
+                    },
+                    "400": {
+                        "description": "export path should be a file path relative to the export directory",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "could not find readme",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    },
+                    "422": {
+                        "description": "the elements use undefined variables",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/readme/{id}/header": {
+            "put": {
+                "description": "Creates a string to be used for a markdown header",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Adds Header",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "readme id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "request body for header",
+                        "name": "addHeader",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.AddHeaderRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "insert the element at this index",
+                        "name": "index",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "insert the element before this element id",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "insert the element after this element id",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "who made the change",
+                        "name": "X-Author",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "returns the header markdown string",
+                        "schema": {
+                            "$ref": "#/definitions/main.ElementResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "header_type should be one of SMALL_HEADING, MEDIUM_HEADING, LARGE_HEADING",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "could not find readme",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/readme/{id}/html": {
+            "get": {
+                "description": "Renders the readme to a html page styled like GitHub, fragment=true returns only the rendered elements.\nThe markdown file is rendered like GitHub flavored markdown, raw html is left out and unsafe links point nowhere.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/html"
+                ],
+                "summary": "Returns a readme as html",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "readme id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "only return the rendered elements",
+                        "name": "fragment",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "the html page",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "could not find readme",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    },
+                    "422": {
+                        "description": "the elements use undefined variables",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/readme/{id}/image": {
+            "put": {
+                "description": "creates a markdown image string",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Add Image",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "readme id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "request body for adding image",
+                        "name": "addLinkRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.AddLinkRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "insert the element at this index",
+                        "name": "index",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "insert the element before this element id",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "insert the element after this element id",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "who made the change",
+                        "name": "X-Author",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "returns created markdown image link",
+                        "schema": {
+                            "$ref": "#/definitions/main.ElementResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "incorrect request body",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "could not find readme",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/readme/{id}/link": {
+            "put": {
+                "description": "creates a markdown link string",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Add Link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "readme id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "request for adding link",
+                        "name": "addLinkRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.AddLinkRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "insert the element at this index",
+                        "name": "index",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "insert the element before this element id",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "insert the element after this element id",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "who made the change",
+                        "name": "X-Author",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "returns created markdown link",
+                        "schema": {
+                            "$ref": "#/definitions/main.ElementResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "incorrect request body",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "could not find readme",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/readme/{id}/list": {
+            "put": {
+                "description": "creates an ordered or unordered markdown list, items can hold nested lists to any depth",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Add List",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "readme id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "request list body",
+                        "name": "addListRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.AddListRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "insert the element at this index",
+                        "name": "index",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "insert the element before this element id",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "insert the element after this element id",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "who made the change",
+                        "name": "X-Author",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "returns the list markdown string",
+                        "schema": {
+                            "$ref": "#/definitions/main.ElementResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "list items cannot be empty",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "could not find readme",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/readme/{id}/paragraph": {
+            "put": {
+                "description": "Updates readme to have a paragraph",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Adds a paragraph",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "readme id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "paragraph you want to add to the readme",
+                        "name": "paragraph",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "insert the element at this index",
+                        "name": "index",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "insert the element before this element id",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "insert the element after this element id",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "who made the change",
+                        "name": "X-Author",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "returns an paragraph markdown string",
+                        "schema": {
+                            "$ref": "#/definitions/main.ElementResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "paragraph param cannot be empty",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "could not find readme",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/readme/{id}/redo": {
+            "post": {
+                "description": "Applies the most recently undone operation again, only possible until the readme is changed some other way.\nThe redo is recorded as a new revision.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Redoes the last undone change to a readme",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "readme id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "who made the change",
+                        "name": "X-Author",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "the revision created by the redo",
+                        "schema": {
+                            "$ref": "#/definitions/main.Revision"
+                        }
+                    },
+                    "404": {
+                        "description": "could not find readme",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    },
+                    "409": {
+                        "description": "nothing to redo",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/readme/{id}/revisions": {
+            "get": {
+                "description": "Every change to a readme creates a numbered revision, the elements of each revision are left out.\nOnly the latest 100 revisions are kept.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Lists the revisions of a readme",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "readme id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "revisions oldest first",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.Revision"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "could not find readme",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/readme/{id}/revisions/{revision}": {
+            "get": {
+                "description": "Takes the same format param as getting the readme",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Returns a readme as of a revision",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "readme id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "revision number",
+                        "name": "revision",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "elements or markdown",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "list of markdown strings",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "unknown format",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "could not find readme or revision",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/readme/{id}/revisions/{revision}/rollback": {
+            "post": {
+                "description": "Replaces the elements with the ones of the revision, the rollback is recorded as a new revision",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Rolls a readme back to a revision",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "readme id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "revision number",
+                        "name": "revision",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "who made the change",
+                        "name": "X-Author",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "the revision created by the rollback",
+                        "schema": {
+                            "$ref": "#/definitions/main.Revision"
+                        }
+                    },
+                    "404": {
+                        "description": "could not find readme or revision",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/readme/{id}/session": {
+            "get": {
+                "description": "Upgrades to a websocket. The client sends SessionOperation messages and receives SessionMessage messages,\nstarting with joined and the current elements. Every change to the readme, whether made in the session or\nthrough any other endpoint, is broadcast to everyone in the session in the order it was made.\nBrowsers can't set headers on websockets so author can be a query param.",
+                "summary": "Joins a collaborative editing session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "readme id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "who makes the changes",
+                        "name": "author",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "who makes the changes",
+                        "name": "X-Author",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "the session messages",
+                        "schema": {
+                            "$ref": "#/definitions/main.SessionMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "could not find readme",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/readme/{id}/snippet": {
+            "put": {
+                "description": "Adds an element standing in for the snippet, the readme renders whatever the snippet holds at the time",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Includes a snippet",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "readme id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "the snippet to include",
+                        "name": "addSnippet",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.AddSnippetRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "insert the element at this index",
+                        "name": "index",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "insert the element before this element id",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "insert the element after this element id",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "who made the change",
+                        "name": "X-Author",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "returns the element id and markdown string",
+                        "schema": {
+                            "$ref": "#/definitions/main.ElementResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "incorrect request body",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "could not find readme or snippet",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/readme/{id}/table": {
+            "put": {
+                "description": "creates a markdown table as a string",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Add Table",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "readme id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "request table body",
+                        "name": "addTableRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.AddTableRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "insert the element at this index",
+                        "name": "index",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "insert the element before this element id",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "insert the element after this element id",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "who made the change",
+                        "name": "X-Author",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "returns table markdown string with values inserted",
+                        "schema": {
+                            "$ref": "#/definitions/main.ElementResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "incorrect request body",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "could not find readme",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/readme/{id}/tasklist": {
+            "put": {
+                "description": "creates a GitHub task list where every item, nested ones included, is a task with a checkbox.\nTasks get an id that can be used to tick them off, the response lists them.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Add Task List",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "readme id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "request list body, items can be checked",
+                        "name": "addListRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.AddListRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "insert the element at this index",
+                        "name": "index",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "insert the element before this element id",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "insert the element after this element id",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "who made the change",
+                        "name": "X-Author",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "returns the task list markdown string and the list with its task ids",
+                        "schema": {
+                            "$ref": "#/definitions/main.ElementResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "list items cannot be empty",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "could not find readme",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/readme/{id}/undo": {
+            "post": {
+                "description": "Puts the elements back to how they were before the most recent add, update, delete, move or rollback,\nor the variables before they were last set or removed.\nThe undo is recorded as a new revision and can be redone.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Undoes the last change to a readme",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "readme id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "who made the change",
+                        "name": "X-Author",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "the revision created by the undo",
+                        "schema": {
+                            "$ref": "#/definitions/main.Revision"
+                        }
+                    },
+                    "404": {
+                        "description": "could not find readme",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    },
+                    "409": {
+                        "description": "nothing to undo",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/readme/{id}/variables": {
+            "get": {
+                "description": "Returns the values filled into {{.Name}} placeholders when the readme is rendered and the placeholders that have no value yet",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Returns the variables of a readme",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "readme id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "the variables",
+                        "schema": {
+                            "$ref": "#/definitions/main.VariablesResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "could not find readme",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    },
+                    "422": {
+                        "description": "an element is not a valid template",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replaces the variables filled into the {{.Name}} placeholders of the elements when the readme is rendered.\nOnce a readme has variables, rendering it fails while an element uses a variable that isn't set\nor any template action other than a placeholder, write {{\"{{\"}} for a literal {{.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Sets the variables of a readme",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "readme id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "variable names and their values",
+                        "name": "variables",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "who made the change",
+                        "name": "X-Author",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "the variables",
+                        "schema": {
+                            "$ref": "#/definitions/main.VariablesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "incorrect request body",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "could not find readme",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    },
+                    "422": {
+                        "description": "an element is not a valid template, nothing was saved",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "The readme renders as written again, placeholders are left in the markdown",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Removes the variables of a readme",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "readme id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "who made the change",
+                        "name": "X-Author",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "returns the readme id",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "could not find readme",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/snippet": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Lists the snippets",
+                "responses": {
+                    "200": {
+                        "description": "every snippet ordered by name",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.Snippet"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Stores elements to be included in readmes with PUT /readme/{id}/snippet",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Creates a snippet",
+                "parameters": [
+                    {
+                        "description": "the snippet",
+                        "name": "snippet",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.Snippet"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "returns the snippet name",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpMessage"
+                        }
+                    },
+                    "400": {
+                        "description": "incorrect request body",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    },
+                    "409": {
+                        "description": "Snippet already exists",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/snippet/usage": {
+            "get": {
+                "description": "Every snippet with the readmes including it, ordered by snippet name. Snippets included in readmes that\ndon't exist are listed too, so the readmes left with a placeholder can be found.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Reports which readmes use each snippet",
+                "responses": {
+                    "200": {
+                        "description": "the readmes using each snippet",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.SnippetUsage"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/snippet/{name}": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Returns a snippet",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "snippet name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "the snippet",
+                        "schema": {
+                            "$ref": "#/definitions/main.Snippet"
+                        }
+                    },
+                    "404": {
+                        "description": "could not find snippet",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Every readme including the snippet renders the new elements from now on",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Replaces a snippet",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "snippet name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "the new snippet, its name is taken from the path",
+                        "name": "snippet",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.Snippet"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "returns the snippet name",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpMessage"
+                        }
+                    },
+                    "400": {
+                        "description": "incorrect request body",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "could not find snippet",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "A snippet still included in readmes can't be deleted, the readmes are listed in the response.\nUndo or redo can include it again afterwards, it then renders as a placeholder comment.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Deletes a snippet",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "snippet name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "returns the deleted snippet name",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "could not find snippet",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    },
+                    "409": {
+                        "description": "readmes still including the snippet",
+                        "schema": {
+                            "$ref": "#/definitions/main.SnippetUsage"
+                        }
+                    }
+                }
+            }
+        },
+        "/template": {
+            "get": {
+                "description": "Returns the built in templates and the ones teams published, ordered by name",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Lists the templates",
+                "responses": {
+                    "200": {
+                        "description": "every template",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.Template"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Stores a template new readmes can be created from with POST /readme?template=name",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Publishes a template",
+                "parameters": [
+                    {
+                        "description": "the template",
+                        "name": "template",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.Template"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "returns the template name",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpMessage"
+                        }
+                    },
+                    "400": {
+                        "description": "incorrect request body",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    },
+                    "409": {
+                        "description": "Template already exists",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    },
+                    "422": {
+                        "description": "the template includes snippets that don't exist",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/template/{name}": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Returns a template",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "template name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "the template",
+                        "schema": {
+                            "$ref": "#/definitions/main.Template"
+                        }
+                    },
+                    "404": {
+                        "description": "could not find template",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replaces the elements and description of a published template, built in templates cannot be changed.\nReadmes already created from the template keep their elements.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Replaces a template",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "template name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "the new template, its name is taken from the path",
+                        "name": "template",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.Template"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "returns the template name",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpMessage"
+                        }
+                    },
+                    "400": {
+                        "description": "incorrect request body",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    },
+                    "403": {
+                        "description": "built in templates cannot be changed",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "could not find template",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    },
+                    "422": {
+                        "description": "the template includes snippets that don't exist",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Readmes already created from the template keep their elements, built in templates cannot be deleted",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Deletes a template",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "template name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "returns the deleted template name",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpMessage"
+                        }
+                    },
+                    "403": {
+                        "description": "built in templates cannot be deleted",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "could not find template",
+                        "schema": {
+                            "$ref": "#/definitions/main.HttpErrorMessage"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "main.AddCodeRequest": {
+            "type": "object",
+            "required": [
+                "code_language",
+                "value"
+            ],
+            "properties": {
+                "code_language": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.AddHeaderRequest": {
+            "type": "object",
+            "required": [
+                "header_type",
+                "value"
+            ],
+            "properties": {
+                "header_type": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.AddLinkRequest": {
+            "type": "object",
+            "required": [
+                "description",
+                "link"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "link": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.AddListRequest": {
+            "type": "object",
+            "required": [
+                "items"
+            ],
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.ListItem"
+                    }
+                },
+                "ordered": {
+                    "type": "boolean"
+                },
+                "start": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.AddSnippetRequest": {
+            "type": "object",
+            "required": [
+                "snippet"
+            ],
+            "properties": {
+                "snippet": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.AddTableRequest": {
+            "type": "object",
+            "required": [
+                "column_names",
+                "column_values"
+            ],
+            "properties": {
+                "column_names": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "column_values": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "main.BatchOperation": {
+            "type": "object",
+            "required": [
+                "operation"
+            ],
+            "properties": {
+                "blockquote": {
+                    "type": "string"
+                },
+                "checked": {
+                    "type": "boolean"
+                },
+                "code": {
+                    "$ref": "#/definitions/main.AddCodeRequest"
+                },
+                "element": {
+                    "$ref": "#/definitions/main.Element"
+                },
+                "element_id": {
+                    "type": "string"
+                },
+                "header": {
+                    "$ref": "#/definitions/main.AddHeaderRequest"
+                },
+                "item_id": {
+                    "type": "string"
+                },
+                "link": {
+                    "$ref": "#/definitions/main.AddLinkRequest"
+                },
+                "list": {
+                    "$ref": "#/definitions/main.AddListRequest"
+                },
+                "operation": {
+                    "description": "OPERATION is one of add_header, add_paragraph, add_blockquote, add_code, add_link, add_image, add_table,\nadd_list, add_tasklist, update, delete, move or toggle",
+                    "type": "string"
+                },
+                "paragraph": {
+                    "type": "string"
+                },
+                "position": {
+                    "description": "POSITION is where an added element goes, the end of the readme by default, or where an element is moved to",
+                    "$ref": "#/definitions/main.MoveElementRequest"
+                },
+                "table": {
+                    "$ref": "#/definitions/main.AddTableRequest"
+                }
+            }
+        },
+        "main.BatchRequest": {
+            "type": "object",
+            "required": [
+                "operations"
+            ],
+            "properties": {
+                "operations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.BatchOperation"
+                    }
+                }
+            }
+        },
+        "main.BatchResponse": {
+            "type": "object",
+            "required": [
+                "results"
+            ],
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.BatchResult"
+                    }
+                },
+                "revision": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.BatchResult": {
+            "type": "object",
+            "required": [
+                "index",
+                "operation",
+                "status"
+            ],
+            "properties": {
+                "element_id": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "markdown": {
+                    "type": "string"
+                },
+                "operation": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.DiffResponse": {
+            "type": "object",
+            "required": [
+                "elements",
+                "from",
+                "to",
+                "unified"
+            ],
+            "properties": {
+                "elements": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.ElementDiff"
+                    }
+                },
+                "from": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "unified": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.Element": {
+            "type": "object",
+            "required": [
+                "type"
+            ],
+            "properties": {
+                "code_language": {
+                    "type": "string"
+                },
+                "column_names": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "column_values": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "level": {
+                    "type": "integer"
+                },
+                "link": {
+                    "type": "string"
+                },
+                "list": {
+                    "$ref": "#/definitions/main.List"
+                },
+                "snippet": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.ElementDiff": {
+            "type": "object",
+            "required": [
+                "element_id",
+                "operation"
+            ],
+            "properties": {
+                "after": {
+                    "$ref": "#/definitions/main.Element"
+                },
+                "before": {
+                    "$ref": "#/definitions/main.Element"
+                },
+                "element_id": {
+                    "type": "string"
+                },
+                "from_index": {
+                    "type": "integer"
+                },
+                "operation": {
+                    "type": "string"
+                },
+                "to_index": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.ElementResponse": {
+            "type": "object",
+            "required": [
+                "id",
+                "message"
+            ],
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "list": {
+                    "description": "LIST is only set for lists and task lists, with the ids of the tasks so they can be ticked off",
+                    "$ref": "#/definitions/main.List"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.ExportRequest": {
+            "type": "object",
+            "properties": {
+                "path": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.HttpErrorMessage": {
+            "type": "object",
+            "required": [
+                "message"
+            ],
+            "properties": {
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.HttpMessage": {
+            "type": "object",
+            "required": [
+                "message"
+            ],
+            "properties": {
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.List": {
+            "type": "object",
+            "required": [
+                "items"
+            ],
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.ListItem"
                     }
+                },
+                "ordered": {
+                    "type": "boolean"
+                },
+                "start": {
+                    "description": "START is the number of the first item of an ordered list, 1 when left out",
+                    "type": "integer"
                 }
             }
-        }
-    },
-    "definitions": {
-        "main.AddCodeRequest": {
+        },
+        "main.ListItem": {
             "type": "object",
             "required": [
-                "code_language",
                 "value"
             ],
             "properties": {
-                "code_language": {
+                "checked": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "description": "ID and CHECKED are only used by task lists, where every item is a task that can be ticked off",
                     "type": "string"
                 },
+                "list": {
+                    "description": "LIST is nested under the item, nil when the item has no children",
+                    "$ref": "#/definitions/main.List"
+                },
                 "value": {
                     "type": "string"
                 }
             }
         },
-        "main.AddHeaderRequest": {
+        "main.MoveElementRequest": {
+            "type": "object",
+            "properties": {
+                "after": {
+                    "type": "string"
+                },
+                "before": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.ReadmeEvent": {
+            "type": "object",
+            "properties": {
+                "change": {
+                    "type": "string"
+                },
+                "element_id": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "markdown": {
+                    "type": "string"
+                },
+                "operation": {
+                    "type": "string"
+                },
+                "revision": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.ReadmeListResponse": {
             "type": "object",
             "required": [
-                "header_type",
-                "value"
+                "page",
+                "page_size",
+                "readmes",
+                "total"
             ],
             "properties": {
-                "header_type": {
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "readmes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.ReadmeSummary"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.ReadmeSpec": {
+            "type": "object",
+            "required": [
+                "elements"
+            ],
+            "properties": {
+                "elements": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.Element"
+                    }
+                },
+                "name": {
                     "type": "string"
                 },
-                "value": {
+                "variables": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "main.ReadmeSummary": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "name": {
                     "type": "string"
                 }
             }
         },
-        "main.AddLinkRequest": {
+        "main.RelativeImages": {
             "type": "object",
             "required": [
-                "description",
-                "link"
+                "file",
+                "links",
+                "readme"
+            ],
+            "properties": {
+                "file": {
+                    "type": "string"
+                },
+                "links": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "readme": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.RelativeImagesResponse": {
+            "type": "object",
+            "required": [
+                "images",
+                "message"
+            ],
+            "properties": {
+                "images": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.RelativeImages"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.Revision": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "elements": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.Element"
+                    }
+                },
+                "number": {
+                    "type": "integer"
+                },
+                "operation": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.SessionMessage": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "type": "string"
+                },
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.ReadmeEvent"
+                    }
+                },
+                "element": {
+                    "$ref": "#/definitions/main.Element"
+                },
+                "elements": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.Element"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                },
+                "operation": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "revision": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.Snippet": {
+            "type": "object",
+            "required": [
+                "elements",
+                "name"
             ],
             "properties": {
                 "description": {
                     "type": "string"
                 },
-                "link": {
+                "elements": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.Element"
+                    }
+                },
+                "name": {
                     "type": "string"
                 }
             }
         },
-        "main.AddTableRequest": {
+        "main.SnippetUsage": {
             "type": "object",
             "required": [
-                "column_names",
-                "column_values"
+                "name",
+                "readmes"
             ],
             "properties": {
-                "column_names": {
+                "name": {
+                    "type": "string"
+                },
+                "readmes": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
+                }
+            }
+        },
+        "main.SpecResponse": {
+            "type": "object",
+            "required": [
+                "id",
+                "markdown"
+            ],
+            "properties": {
+                "id": {
+                    "type": "string"
                 },
-                "column_values": {
-                    "type": "object",
-                    "additionalProperties": {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        }
-                    }
+                "markdown": {
+                    "type": "string"
                 }
             }
         },
-        "main.HttpErrorMessage": {
+        "main.TaskItemResponse": {
             "type": "object",
             "required": [
+                "element_id",
+                "item_id",
                 "message"
             ],
             "properties": {
+                "checked": {
+                    "type": "boolean"
+                },
+                "element_id": {
+                    "type": "string"
+                },
+                "item_id": {
+                    "type": "string"
+                },
                 "message": {
                     "type": "string"
                 }
             }
         },
-        "main.HttpMessage": {
+        "main.Template": {
             "type": "object",
             "required": [
-                "message"
+                "elements",
+                "name"
             ],
             "properties": {
-                "message": {
+                "builtin": {
+                    "type": "boolean"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "elements": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.Element"
+                    }
+                },
+                "name": {
                     "type": "string"
                 }
             }
+        },
+        "main.VariablesResponse": {
+            "type": "object",
+            "required": [
+                "undefined",
+                "variables"
+            ],
+            "properties": {
+                "undefined": {
+                    "description": "UNDEFINED lists the variables the elements use that have no value",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "variables": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
         }
     }
 }`